@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// AddAdminRoutes adds the /admin API, which requires the admin token as a bearer token.
+func AddAdminRoutes(router *mux.Router, admin *leaderboard.Admin, token string) {
+	adminRouter := router.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(RequireToken(token))
+	adminRouter.HandleFunc("/exclusions", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, struct {
+			Exclusions []leaderboard.Exclusion `json:"exclusions"`
+		}{admin.Exclusions()})
+	}).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/exclusions", func(w http.ResponseWriter, r *http.Request) {
+		EndpointAddExclusion(w, r, admin)
+	}).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/exclusions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		WriteAdminResult(w, admin.RemoveExclusion(mux.Vars(r)["id"]), http.StatusNoContent)
+	}).Methods(http.MethodDelete)
+	adminRouter.HandleFunc("/competitions/{id}/recompute", func(w http.ResponseWriter, r *http.Request) {
+		WriteAdminResult(w, admin.Recompute(mux.Vars(r)["id"]), http.StatusAccepted)
+	}).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/competitions/{id}/pause", func(w http.ResponseWriter, r *http.Request) {
+		WriteAdminResult(w, admin.Pause(mux.Vars(r)["id"]), http.StatusNoContent)
+	}).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/competitions/{id}/resume", func(w http.ResponseWriter, r *http.Request) {
+		WriteAdminResult(w, admin.Resume(mux.Vars(r)["id"]), http.StatusNoContent)
+	}).Methods(http.MethodPost)
+}
+
+// RequireToken rejects requests without the token in an "Authorization: Bearer" header.
+func RequireToken(token string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if !strings.HasPrefix(header, "Bearer ") {
+				WriteError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			given := strings.TrimPrefix(header, "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				WriteError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func EndpointAddExclusion(w http.ResponseWriter, r *http.Request, admin *leaderboard.Admin) {
+	var exclusion leaderboard.Exclusion
+	if err := json.NewDecoder(r.Body).Decode(&exclusion); err != nil {
+		WriteError(w, http.StatusBadRequest, "invalid exclusion: "+err.Error())
+		return
+	}
+	added, err := admin.AddExclusion(exclusion)
+	if errors.Is(err, leaderboard.ErrInvalidExclusion) {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		WriteAdminResult(w, err, http.StatusCreated)
+		return
+	}
+	log.WithFields(log.Fields{"id": added.ID, "reason": added.Reason}).Info("Exclusion added")
+	WriteJSON(w, http.StatusCreated, added)
+}
+
+// WriteAdminResult writes the status code of a successful admin change, or the error.
+func WriteAdminResult(w http.ResponseWriter, err error, statusCode int) {
+	switch err {
+	case nil:
+		w.WriteHeader(statusCode)
+	case leaderboard.ErrCompetitionNotFound, leaderboard.ErrExclusionNotFound:
+		WriteError(w, http.StatusNotFound, err.Error())
+	default:
+		log.WithError(err).Error("Admin change failed")
+		WriteError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// WriteJSON writes a value as JSON with the given status code.
+func WriteJSON(w http.ResponseWriter, statusCode int, value interface{}) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		log.WithError(err).Error("Error marshaling response")
+		WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(payload)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/datastore"
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+)
+
+// mongoPingTimeout bounds the MongoDB check of /readyz.
+const mongoPingTimeout = 2 * time.Second
+
+// EndpointHealthz reports that the process is serving, for liveness probes.
+func EndpointHealthz(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, struct {
+		Status string `json:"status"`
+	}{"ok"})
+}
+
+// EndpointReadyz runs the readiness checks, and returns 503 if any fails so that traffic
+// is no longer routed to this instance.
+func EndpointReadyz(w http.ResponseWriter, r *http.Request, host *leaderboard.Host, ds *datastore.Service) {
+	checks := host.ReadinessChecks(r.Context(), time.Now())
+	if ds != nil {
+		checks = append(checks, checkMongo(r.Context(), ds))
+	}
+
+	ready := true
+	for _, check := range checks {
+		ready = ready && check.Healthy
+	}
+	statusCode := http.StatusOK
+	if !ready {
+		statusCode = http.StatusServiceUnavailable
+	}
+	WriteJSON(w, statusCode, struct {
+		Ready  bool                      `json:"ready"`
+		Checks []leaderboard.HealthCheck `json:"checks"`
+	}{ready, checks})
+}
+
+// checkMongo pings MongoDB if the service is using it. MongoDB is best effort, so a
+// connection that failed at startup is reported but does not fail the check.
+func checkMongo(ctx context.Context, ds *datastore.Service) leaderboard.HealthCheck {
+	check := leaderboard.HealthCheck{Name: "mongo", Healthy: true}
+	if !ds.IsConnected() {
+		check.Message = "not connected, running without MongoDB"
+		return check
+	}
+	ctx, cancel := context.WithTimeout(ctx, mongoPingTimeout)
+	defer cancel()
+	if err := ds.Ping(ctx); err != nil {
+		check.Healthy = false
+		check.Message = err.Error()
+	}
+	return check
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
@@ -13,11 +14,14 @@ import (
 	"time"
 
 	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/datastore"
 	"github.com/vegaprotocol/topgun-service/leaderboard"
+	"github.com/vegaprotocol/topgun-service/metrics"
+	"github.com/vegaprotocol/topgun-service/util"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
-	"github.com/jinzhu/configor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -31,17 +35,9 @@ func main() {
 		os.Exit(1)
 	}
 
-	var cfg config.Config
-	err := configor.Load(&cfg, configName)
-	// https://github.com/jinzhu/configor/issues/40
-	if err != nil && !strings.Contains(err.Error(), "should be struct") {
-		fmt.Printf("Failed to read config: %v", err)
-		os.Exit(1)
-	}
-
-	err = config.CheckConfig(cfg)
-	if err != nil && !strings.Contains(err.Error(), "should be struct") {
-		fmt.Printf("Invalid config: %v", err)
+	cfg, err := LoadConfig(configName)
+	if err != nil {
+		fmt.Printf("%v", err)
 		os.Exit(1)
 	}
 
@@ -53,29 +49,119 @@ func main() {
 	}
 	log.WithFields(cfg.LogFields()).Info("Starting server")
 
-	svc := leaderboard.NewLeaderboardService(cfg)
+	host := leaderboard.NewHost(cfg)
+
+	var ds *datastore.Service
+	adminEnabled := len(cfg.AdminToken) > 0
+	if cfg.SnapshotEnabled || adminEnabled || len(cfg.SocialCollectionName) > 0 || (len(cfg.BaselineDir) == 0 && usesBaselines(cfg)) {
+		// MongoDB is best effort, the leaderboard still runs without it
+		ds = datastore.NewMongoDbDatastore(context.Background(), cfg.MongoConnectionString, cfg.MongoTimeout)
+		if err := ds.Connect(); err != nil {
+			log.WithError(err).Warn("Failed to connect to MongoDB, snapshots, baselines, socials collection and admin changes persistence disabled")
+		} else {
+			if cfg.SnapshotEnabled {
+				snapshots := leaderboard.NewMongoSnapshotStore(ds, cfg.MongoDatabaseName, cfg.MongoCollectionName)
+				if err := snapshots.EnsureIndexes(cfg.SnapshotRetention); err != nil {
+					log.WithError(err).Warn("Failed to create snapshot indexes")
+				}
+				host.SetSnapshotStore(snapshots)
+			}
+			if len(cfg.SocialCollectionName) > 0 {
+				host.SetVerifier(leaderboard.NewVerifier(cfg, ds))
+			}
+		}
+	}
+	if len(cfg.BaselineDir) > 0 {
+		host.SetBaselineStore(leaderboard.NewFileBaselineStore(cfg.BaselineDir))
+	} else if ds != nil && ds.IsConnected() {
+		host.SetBaselineStore(leaderboard.NewMongoBaselineStore(ds, cfg.MongoDatabaseName, cfg.MongoCollectionName+"_baselines"))
+	}
 
 	router := mux.NewRouter()
-	router.HandleFunc("/", EndpointRoot)
-	router.HandleFunc("/status", EndpointStatus)
-	router.HandleFunc("/leaderboard", func(w http.ResponseWriter, r *http.Request) {
-		EndpointLeaderboard(w, r, svc)
+	router.Use(metrics.Middleware)
+	// Streams stay open, every other route is bounded by a write timeout
+	streams := router.NewRoute().Subrouter()
+	api := router.NewRoute().Subrouter()
+	api.Use(WriteTimeout(time.Second * 15))
+	api.Handle("/metrics", promhttp.Handler())
+	if adminEnabled {
+		// The admin state is restored before the first update
+		var adminStore leaderboard.AdminStore
+		if ds != nil && ds.IsConnected() {
+			adminStore = leaderboard.NewMongoAdminStore(ds, cfg.MongoDatabaseName, cfg.MongoCollectionName+"_admin")
+		} else {
+			log.Warn("Admin changes are not persisted without MongoDB, they are lost on restart")
+		}
+		AddAdminRoutes(api, leaderboard.NewAdmin(host, adminStore), cfg.AdminToken)
+	}
+	api.HandleFunc("/", EndpointRoot)
+	api.HandleFunc("/status", EndpointStatus)
+	api.HandleFunc("/healthz", EndpointHealthz)
+	api.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		EndpointReadyz(w, r, host, ds)
+	})
+	// The first competition stays on /leaderboard for existing frontends
+	api.HandleFunc("/leaderboard", func(w http.ResponseWriter, r *http.Request) {
+		EndpointLeaderboard(w, r, host.Default())
+	})
+	api.HandleFunc("/leaderboard/history", func(w http.ResponseWriter, r *http.Request) {
+		EndpointLeaderboardHistory(w, r, host.Default())
 	})
-	router.HandleFunc("/leaderboard", func(w http.ResponseWriter, r *http.Request) {
+	api.HandleFunc("/participants/{publicKey}", func(w http.ResponseWriter, r *http.Request) {
+		EndpointParticipant(w, r, host.Default())
+	})
+	streams.HandleFunc("/leaderboard/stream", func(w http.ResponseWriter, r *http.Request) {
+		EndpointLeaderboardStream(w, r, host.Default())
+	})
+	api.HandleFunc("/competitions", func(w http.ResponseWriter, r *http.Request) {
+		EndpointCompetitions(w, r, host)
+	})
+	api.HandleFunc("/competitions/{id}/leaderboard", func(w http.ResponseWriter, r *http.Request) {
+		svc, found := host.Get(mux.Vars(r)["id"])
+		if !found {
+			WriteError(w, http.StatusNotFound, "competition not found")
+			return
+		}
 		EndpointLeaderboard(w, r, svc)
-	}).Queries("q", "{q}")
+	})
+	api.HandleFunc("/competitions/{id}/leaderboard/history", func(w http.ResponseWriter, r *http.Request) {
+		svc, found := host.Get(mux.Vars(r)["id"])
+		if !found {
+			WriteError(w, http.StatusNotFound, "competition not found")
+			return
+		}
+		EndpointLeaderboardHistory(w, r, svc)
+	})
+	api.HandleFunc("/competitions/{id}/participants/{publicKey}", func(w http.ResponseWriter, r *http.Request) {
+		svc, found := host.Get(mux.Vars(r)["id"])
+		if !found {
+			WriteError(w, http.StatusNotFound, "competition not found")
+			return
+		}
+		EndpointParticipant(w, r, svc)
+	})
+	streams.HandleFunc("/competitions/{id}/leaderboard/stream", func(w http.ResponseWriter, r *http.Request) {
+		svc, found := host.Get(mux.Vars(r)["id"])
+		if !found {
+			WriteError(w, http.StatusNotFound, "competition not found")
+			return
+		}
+		EndpointLeaderboardStream(w, r, svc)
+	})
 
 	srv := &http.Server{
-		Addr:         cfg.Listen,
-		WriteTimeout: time.Second * 15,
-		ReadTimeout:  time.Second * 15,
-		IdleTimeout:  time.Second * 60,
-		Handler:      handlers.CORS(handlers.AllowedOrigins([]string{"*"}))(router),
+		Addr:        cfg.Listen,
+		ReadTimeout: time.Second * 15,
+		IdleTimeout: time.Second * 60,
+		Handler:     handlers.CORS(handlers.AllowedOrigins([]string{"*"}))(router),
 	}
 
-	// Run the leaderboard service in its own goroutine
+	// Apply safe config changes on SIGHUP, without a restart
+	ReloadOnSignal(configName, host)
+
+	// Run the leaderboard services in their own goroutine
 	go func() {
-		svc.Start()
+		host.Start()
 	}()
 	// Run the web server in its own goroutine
 	go func() {
@@ -96,8 +182,13 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.GracefulShutdownTimeout)
 	defer cancel()
 
-	// Signal to stop the leaderboard service
-	svc.Stop()
+	// Signal to stop the leaderboard services
+	host.Stop()
+	if ds != nil {
+		if err := ds.Disconnect(); err != nil {
+			log.WithError(err).Warn("Failed to disconnect from MongoDB")
+		}
+	}
 
 	// Doesn't block if no connections, but will otherwise wait
 	// until the timeout deadline.
@@ -110,6 +201,16 @@ func main() {
 	os.Exit(0)
 }
 
+// usesBaselines returns true if any competition captures or reads baselines.
+func usesBaselines(cfg config.Config) bool {
+	for _, comp := range cfg.CompetitionList() {
+		if leaderboard.UsesBaselines(comp) {
+			return true
+		}
+	}
+	return false
+}
+
 type ErrorObject struct {
 	Error string `json:"error"`
 }
@@ -131,7 +232,27 @@ func GetQueryInt(r *http.Request, key string) int64 {
 	return -1
 }
 
+// GetSort returns the sort and order query string params.
+func GetSort(r *http.Request) (leaderboard.Sort, error) {
+	return leaderboard.NewSort(GetQuery(r, "sort"), GetQuery(r, "order"))
+}
+
 func EndpointLeaderboard(w http.ResponseWriter, r *http.Request, svc *leaderboard.Service) {
+	var at *time.Time
+	if value := GetQuery(r, "at"); len(value) > 0 {
+		t, err := util.ParseTimestamp(value)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		at = &t
+	}
+	by, err := GetSort(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	responseType := GetQuery(r, "type")
 	if strings.ToLower(responseType) == "csv" {
 		w.Header().Set("Content-Type", "text/plain")
@@ -139,13 +260,19 @@ func EndpointLeaderboard(w http.ResponseWriter, r *http.Request, svc *leaderboar
 		skip := GetQueryInt(r, "skip")
 		size := GetQueryInt(r, "size")
 		blacklisted := strings.ToLower(GetQuery(r, "blacklisted")) == "true"
-		payload, err := svc.CsvLeaderboard(q, skip, size, blacklisted)
+		var payload []byte
+		var err error
+		if at != nil {
+			payload, err = svc.CsvLeaderboardAt(*at, q, skip, size, blacklisted, by)
+		} else {
+			payload, err = svc.CsvLeaderboard(q, skip, size, blacklisted, by)
+		}
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error": err.Error(),
 			}).Error("Error marshaling leaderboard")
 			payload = []byte(err.Error())
-			w.WriteHeader(http.StatusInternalServerError)
+			w.WriteHeader(errorStatusCode(err))
 		} else {
 			w.WriteHeader(http.StatusOK)
 		}
@@ -156,12 +283,19 @@ func EndpointLeaderboard(w http.ResponseWriter, r *http.Request, svc *leaderboar
 		skip := GetQueryInt(r, "skip")
 		size := GetQueryInt(r, "size")
 		blacklisted := strings.ToLower(GetQuery(r, "blacklisted")) == "true"
-		payload, err := svc.JsonLeaderboard(q, skip, size, blacklisted)
+		var payload []byte
+		var err error
+		if at != nil {
+			payload, err = svc.JsonLeaderboardAt(*at, q, skip, size, blacklisted, by)
+		} else {
+			payload, err = svc.JsonLeaderboard(q, skip, size, blacklisted, by)
+		}
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error": err.Error(),
 			}).Error("Error marshaling leaderboard")
 
+			statusCode := errorStatusCode(err)
 			payload, err = json.Marshal(ErrorObject{Error: err.Error()})
 			if err != nil {
 				log.WithFields(log.Fields{
@@ -169,7 +303,7 @@ func EndpointLeaderboard(w http.ResponseWriter, r *http.Request, svc *leaderboar
 				}).Error("Error marshaling error message during marshaling of leaderboard")
 				payload = []byte("{\"error\":\"\"}")
 			}
-			w.WriteHeader(http.StatusInternalServerError)
+			w.WriteHeader(statusCode)
 		} else {
 			w.WriteHeader(http.StatusOK)
 		}
@@ -177,6 +311,76 @@ func EndpointLeaderboard(w http.ResponseWriter, r *http.Request, svc *leaderboar
 	}
 }
 
+func EndpointLeaderboardHistory(w http.ResponseWriter, r *http.Request, svc *leaderboard.Service) {
+	publicKey := GetQuery(r, "publicKey")
+	if len(publicKey) == 0 {
+		WriteError(w, http.StatusBadRequest, "missing: publicKey")
+		return
+	}
+	payload, err := svc.JsonParticipantHistory(publicKey)
+	if err != nil {
+		log.WithError(err).Error("Error loading participant history")
+		WriteError(w, errorStatusCode(err), err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+}
+
+func EndpointParticipant(w http.ResponseWriter, r *http.Request, svc *leaderboard.Service) {
+	payload, err := svc.JsonParticipant(mux.Vars(r)["publicKey"])
+	if err != nil {
+		if err != leaderboard.ErrParticipantNotFound {
+			log.WithError(err).Error("Error marshaling participant")
+		}
+		WriteError(w, errorStatusCode(err), err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+}
+
+// errorStatusCode maps leaderboard errors to HTTP status codes.
+func errorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, leaderboard.ErrHistoryUnavailable):
+		return http.StatusNotImplemented
+	case errors.Is(err, leaderboard.ErrNoSnapshot), errors.Is(err, leaderboard.ErrParticipantNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, leaderboard.ErrUnknownMetric), errors.Is(err, leaderboard.ErrInvalidOrder):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// WriteError writes a JSON ErrorObject with the given status code.
+func WriteError(w http.ResponseWriter, statusCode int, message string) {
+	payload, err := json.Marshal(ErrorObject{Error: message})
+	if err != nil {
+		payload = []byte("{\"error\":\"\"}")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(payload)
+}
+
+func EndpointCompetitions(w http.ResponseWriter, r *http.Request, host *leaderboard.Host) {
+	payload, err := json.Marshal(struct {
+		Competitions []leaderboard.CompetitionSummary `json:"competitions"`
+	}{host.Summaries()})
+	if err != nil {
+		log.WithError(err).Error("Error marshaling competitions")
+		WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+}
+
 func EndpointStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -194,7 +398,10 @@ func EndpointRoot(w http.ResponseWriter, r *http.Request) {
 <h1>Topgun Service</h1>
 <ul>
 <li><a href="/status">Status</a></li>
+<li><a href="/readyz">Readiness</a></li>
 <li><a href="/leaderboard">Leaderboard</a></li>
+<li><a href="/competitions">Competitions</a></li>
+<li><a href="/metrics">Metrics</a></li>
 </ul>
 </body>
 </html>`
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/jinzhu/configor"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// LoadConfig reads and checks a config file.
+func LoadConfig(configName string) (config.Config, error) {
+	var cfg config.Config
+	err := configor.Load(&cfg, configName)
+	// https://github.com/jinzhu/configor/issues/40
+	if err != nil && !strings.Contains(err.Error(), "should be struct") {
+		return cfg, errors.Wrap(err, "failed to read config")
+	}
+
+	err = config.CheckConfig(cfg, leaderboard.AlgorithmValidator{})
+	if err != nil && !strings.Contains(err.Error(), "should be struct") {
+		return cfg, errors.Wrap(err, "invalid config")
+	}
+	return cfg, nil
+}
+
+// ReloadOnSignal reloads the config file on SIGHUP. An invalid config is rejected, and the
+// current config keeps running.
+func ReloadOnSignal(configName string, host *leaderboard.Host) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	go func() {
+		for range c {
+			log.WithField("config", configName).Info("Reloading config")
+			cfg, err := LoadConfig(configName)
+			if err != nil {
+				log.WithError(err).Error("Config reload rejected, keeping the current config")
+				continue
+			}
+			if err := config.ConfigureLogging(cfg); err != nil {
+				log.WithError(err).Error("Config reload rejected, keeping the current config")
+				continue
+			}
+			host.Reload(cfg)
+		}
+	}()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// streamKeepAlive is the interval of the comments sent on idle streams, so that proxies
+// do not close them.
+const streamKeepAlive = 30 * time.Second
+
+// EndpointLeaderboardStream streams the leaderboard as Server-Sent Events. The current board
+// is sent straight away, and again after every update, filtered and sorted like EndpointLeaderboard.
+func EndpointLeaderboardStream(w http.ResponseWriter, r *http.Request, svc *leaderboard.Service) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	q := GetQuery(r, "q")
+	skip := GetQueryInt(r, "skip")
+	size := GetQueryInt(r, "size")
+	blacklisted := strings.ToLower(GetQuery(r, "blacklisted")) == "true"
+	by, err := GetSort(r)
+	if err == nil {
+		err = svc.CheckSort(by)
+	}
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updates, unsubscribe := svc.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Stops nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func() {
+		payload, err := svc.JsonLeaderboard(q, skip, size, blacklisted, by)
+		if err != nil {
+			log.WithError(err).Error("Error marshaling leaderboard")
+			payload, _ = json.Marshal(ErrorObject{Error: err.Error()})
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", payload)
+		} else {
+			fmt.Fprintf(w, "event: leaderboard\ndata: %s\n\n", payload)
+		}
+		flusher.Flush()
+	}
+	send()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case _, open := <-updates:
+			if !open {
+				return
+			}
+			send()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// WriteTimeout bounds the time to write a response. It replaces the server's WriteTimeout,
+// which would also end streams.
+func WriteTimeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(next, timeout, "{\"error\":\"timeout\"}")
+	}
+}
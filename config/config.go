@@ -5,6 +5,8 @@ package config
 import (
 	"fmt"
 	"net/url"
+	"path"
+	"regexp"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -17,10 +19,18 @@ type Config struct {
 	// Listen specifies the IP address and port to listen on, e.g. 127.0.0.1:1234, 0.0.0.0:5678
 	Listen string `yaml:"listen"`
 
+	// AdminToken enables the /admin API, which requires it as a bearer token. The API is
+	// disabled if it is empty.
+	AdminToken string `yaml:"adminToken"`
+
 	LogFormat     string `yaml:"logFormat"`
 	LogLevel      string `yaml:"logLevel"`
 	LogMethodName bool   `yaml:"logMethodName"`
 
+	// ID identifies the competition when the top-level fields describe a single competition.
+	// Defaults to DefaultCompetitionID.
+	ID string `yaml:"id"`
+
 	// Algorithm describes the sorting method for ordering participants
 	Algorithm string `yaml:"algorithm"`
 
@@ -34,11 +44,25 @@ type Config struct {
 
 	DefaultSort string `yaml:"defaultSort"`
 
+	// TieBreak orders participants with the same score, see the TieBreak constants.
+	// Defaults to TieBreakPublicKey.
+	TieBreak string `yaml:"tieBreak"`
+
+	// Ranking sets how positions are numbered, see the Ranking constants.
+	// Defaults to RankingSequential.
+	Ranking string `yaml:"ranking"`
+
 	GracefulShutdownTimeout time.Duration `yaml:"gracefulShutdownTimeout"`
 
 	Headers []string `yaml:"headers"`
 
-	SocialURL *url.URL `yaml:"socialURL"`
+	// SocialURL is the social media verification API. Verified socials can also, or instead,
+	// be loaded from SocialFile (JSON or CSV), the MongoDB collection SocialCollectionName,
+	// or listed in Socials. When several are set their socials are merged, in that order.
+	SocialURL            *url.URL `yaml:"socialURL"`
+	SocialFile           string   `yaml:"socialFile"`
+	SocialCollectionName string   `yaml:"socialCollectionName"`
+	Socials              []Social `yaml:"socials"`
 
 	VegaAssets []string `yaml:"vegaAssets"`
 
@@ -46,6 +70,14 @@ type Config struct {
 
 	VegaGraphQLURL *url.URL `yaml:"vegaGraphQLURL"`
 
+	// VegaGraphQLURLs are further data nodes to fail over to when vegaGraphQLURL is unavailable.
+	VegaGraphQLURLs []*url.URL `yaml:"vegaGraphQLURLs"`
+
+	// VegaRequestTimeout is the timeout of a single data node request, and VegaRetries the number
+	// of times a failed request is retried. Zero uses the defaults of the datanode package.
+	VegaRequestTimeout time.Duration `yaml:"vegaRequestTimeout"`
+	VegaRetries        int           `yaml:"vegaRetries"`
+
 	VegaPoll time.Duration `yaml:"vegaPoll"`
 
 	StartTime time.Time `yaml:"startTime"`
@@ -56,60 +88,323 @@ type Config struct {
 	MongoCollectionName   string `yaml:"mongoCollectionName"`
 	MongoDatabaseName     string `yaml:"mongoDatabaseName"`
 
-	// Set to false to disable creation of snapshot json files
+	// MongoTimeout bounds every MongoDB operation, so that an unresponsive MongoDB cannot hold
+	// up leaderboard updates. Zero uses the default of the datastore package.
+	MongoTimeout time.Duration `yaml:"mongoTimeout"`
+
+	// Set to true to write a snapshot of every leaderboard update to the MongoDB collection,
+	// and to restore the latest snapshot on startup
 	SnapshotEnabled bool `yaml:"snapshotEnabled"`
 
-	// TwitterBlacklist describes a set of users who should be filtered from the public leaderboard results
+	// SnapshotRetention, when set, is how long snapshots are kept before MongoDB deletes them.
+	// Zero keeps them forever. It must be longer than every competition, whose baselines,
+	// period boundaries and history are read from snapshots.
+	SnapshotRetention time.Duration `yaml:"snapshotRetention"`
+
+	// Exclusions lists the public keys and social accounts that are filtered from the public
+	// leaderboard results, e.g. team members and known bots.
+	Exclusions []Exclusion `yaml:"exclusions"`
+
+	// TwitterBlacklist describes a set of users who should be filtered from the public leaderboard results,
+	// keyed by provider:userID, e.g. discord:80351110224678912. Bare keys are Twitter user IDs.
+	// Deprecated: use Exclusions, which the entries are added to.
 	TwitterBlacklist map[string]string `yaml:"twitterBlacklist"`
+
+	// BaselineDir is a directory of baseline files, <baselineDir>/<competition id>/<name>.json.
+	// If empty, baselines are kept in MongoDB.
+	BaselineDir string `yaml:"baselineDir"`
+
+	// Baselines maps a baseline name to the time at which the leaderboard is frozen under that name,
+	// e.g. the end of day 1. Algorithms refer to baselines by name in algorithmConfig.
+	Baselines map[string]time.Time `yaml:"baselines"`
+
+	// ReferencePeriod sets the reference of the position changes shown to participants: the
+	// board at the start of the current period, e.g. the start of the UTC day for 24h.
+	// Defaults to DefaultReferencePeriod.
+	ReferencePeriod time.Duration `yaml:"referencePeriod"`
+
+	// Competitions describes several competitions hosted by one service. When set, the
+	// competition-specific top-level fields (algorithm, assets, times etc.) are ignored.
+	Competitions []Competition `yaml:"competitions"`
 }
 
-func CheckConfig(cfg Config) error {
-	var e *multierror.Error
+// Social is a verified social listed in the config, e.g. for an internal test competition.
+// Provider is twitter, discord, github or wallet, and defaults to twitter if a handle is set.
+type Social struct {
+	PartyID  string `yaml:"partyID"`
+	Provider string `yaml:"provider"`
+	Handle   string `yaml:"handle"`
+	UserID   string `yaml:"userID"`
+}
 
-	if len(cfg.Listen) == 0 {
-		e = multierror.Append(e, errors.New("missing: listen"))
+// Exclusion matches the participants to filter from the public leaderboard results by one of
+// a public key, a social user ID, or a handle pattern. Provider limits UserID and Handle to
+// one identity provider. Handle is matched case-insensitively and may contain the wildcards
+// of path.Match, e.g. "*_bot". An exclusion without Expires never expires.
+type Exclusion struct {
+	PublicKey string    `yaml:"publicKey"`
+	Provider  string    `yaml:"provider"`
+	UserID    string    `yaml:"userID"`
+	Handle    string    `yaml:"handle"`
+	Reason    string    `yaml:"reason"`
+	Expires   time.Time `yaml:"expires"`
+}
+
+// DefaultCompetitionID is the ID given to a competition described by the top-level config fields.
+const DefaultCompetitionID = "default"
+
+// DefaultReferencePeriod makes the start of the UTC day the reference of position changes.
+const DefaultReferencePeriod = 24 * time.Hour
+
+// Tie-break policies for participants with the same score. Parties still tied after the
+// policy is applied, e.g. with no recorded action, are ordered by public key.
+const (
+	TieBreakPublicKey    = "publicKey"
+	TieBreakRegistration = "registration"
+	TieBreakFirstAction  = "firstAction"
+)
+
+// Ranking modes. Sequential numbers participants 1, 2, 3, 4 even when tied, shared gives
+// tied participants the same position, e.g. 1, 2, 2, 4.
+const (
+	RankingSequential = "sequential"
+	RankingShared     = "shared"
+)
+
+// Competition describes the competition-specific part of the config.
+type Competition struct {
+	// ID is used in URLs, e.g. /competitions/{id}/leaderboard
+	ID string `yaml:"id"`
+
+	Algorithm       string            `yaml:"algorithm"`
+	AlgorithmConfig map[string]string `yaml:"algorithmConfig"`
+	Description     string            `yaml:"description"`
+	DefaultDisplay  string            `yaml:"defaultDisplay"`
+	DefaultSort     string            `yaml:"defaultSort"`
+	TieBreak        string            `yaml:"tieBreak"`
+	Ranking         string            `yaml:"ranking"`
+	Headers         []string          `yaml:"headers"`
+	VegaAssets      []string          `yaml:"vegaAssets"`
+	MarketIDs       []string          `yaml:"marketIDs"`
+	StartTime       time.Time         `yaml:"startTime"`
+	EndTime         time.Time         `yaml:"endTime"`
+
+	Baselines map[string]time.Time `yaml:"baselines"`
+
+	ReferencePeriod time.Duration `yaml:"referencePeriod"`
+}
+
+// CompetitionList returns the configured competitions. If no competitions list is
+// set, a single competition is made from the top-level fields.
+func (c *Config) CompetitionList() []Competition {
+	if len(c.Competitions) > 0 {
+		return c.Competitions
 	}
-	if len(cfg.LogFormat) == 0 {
-		e = multierror.Append(e, errors.New("missing: logFormat"))
+	id := c.ID
+	if id == "" {
+		id = DefaultCompetitionID
 	}
-	if len(cfg.LogLevel) == 0 {
-		e = multierror.Append(e, errors.New("missing: logLevel"))
+	return []Competition{{
+		ID:              id,
+		Algorithm:       c.Algorithm,
+		AlgorithmConfig: c.AlgorithmConfig,
+		Description:     c.Description,
+		DefaultDisplay:  c.DefaultDisplay,
+		DefaultSort:     c.DefaultSort,
+		TieBreak:        c.TieBreak,
+		Ranking:         c.Ranking,
+		Headers:         c.Headers,
+		VegaAssets:      c.VegaAssets,
+		MarketIDs:       c.MarketIDs,
+		StartTime:       c.StartTime,
+		EndTime:         c.EndTime,
+		Baselines:       c.Baselines,
+		ReferencePeriod: c.ReferencePeriod,
+	}}
+}
+
+// ForCompetition returns a copy of the config with the top-level competition fields
+// replaced by those of the given competition.
+func (c Config) ForCompetition(comp Competition) Config {
+	c.ID = comp.ID
+	c.Algorithm = comp.Algorithm
+	c.AlgorithmConfig = comp.AlgorithmConfig
+	c.Description = comp.Description
+	c.DefaultDisplay = comp.DefaultDisplay
+	c.DefaultSort = comp.DefaultSort
+	c.TieBreak = comp.TieBreak
+	c.Ranking = comp.Ranking
+	c.Headers = comp.Headers
+	c.VegaAssets = comp.VegaAssets
+	c.MarketIDs = comp.MarketIDs
+	c.StartTime = comp.StartTime
+	c.EndTime = comp.EndTime
+	c.Baselines = comp.Baselines
+	c.ReferencePeriod = comp.ReferencePeriod
+	c.Competitions = nil
+	return c
+}
+
+var competitionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func checkCompetition(comp Competition, algorithms AlgorithmValidator) error {
+	var e *multierror.Error
+
+	if !competitionIDPattern.MatchString(comp.ID) {
+		e = multierror.Append(e, errors.New("invalid: id (letters, digits, '-' and '_' only)"))
 	}
-	if len(cfg.Algorithm) == 0 {
+	if len(comp.Algorithm) == 0 {
 		e = multierror.Append(e, errors.New("missing: algorithm"))
+	} else if algorithms != nil {
+		if err := algorithms.ValidateAlgorithm(comp.Algorithm, comp.AlgorithmConfig); err != nil {
+			e = multierror.Append(e, errors.Wrap(err, "invalid: algorithm"))
+		}
 	}
-	if len(cfg.Description) == 0 {
+	if len(comp.Description) == 0 {
 		e = multierror.Append(e, errors.New("missing: description"))
 	}
-	if len(cfg.DefaultDisplay) == 0 {
+	if len(comp.DefaultDisplay) == 0 {
 		e = multierror.Append(e, errors.New("missing: defaultDisplay"))
 	}
-	if len(cfg.DefaultSort) == 0 {
+	if len(comp.DefaultSort) == 0 {
 		e = multierror.Append(e, errors.New("missing: defaultSort"))
+	} else if len(comp.Algorithm) > 0 && algorithms != nil {
+		if err := algorithms.ValidateSort(comp.Algorithm, comp.DefaultSort); err != nil {
+			e = multierror.Append(e, err)
+		}
 	}
-	if cfg.GracefulShutdownTimeout <= 0 {
-		e = multierror.Append(e, errors.New("invalid: gracefulShutdownTimeout (should be greater than 0)"))
+	switch comp.TieBreak {
+	case "", TieBreakPublicKey, TieBreakRegistration, TieBreakFirstAction:
+	default:
+		e = multierror.Append(e, fmt.Errorf("invalid: tieBreak %q (%s, %s or %s)",
+			comp.TieBreak, TieBreakPublicKey, TieBreakRegistration, TieBreakFirstAction))
 	}
-	if len(cfg.Headers) == 0 {
-		e = multierror.Append(e, errors.New("missing: headers"))
+	switch comp.Ranking {
+	case "", RankingSequential, RankingShared:
+	default:
+		e = multierror.Append(e, fmt.Errorf("invalid: ranking %q (%s or %s)", comp.Ranking, RankingSequential, RankingShared))
 	}
-	if cfg.SocialURL == nil || cfg.SocialURL.String() == "" {
-		e = multierror.Append(e, errors.New("missing: socialURL"))
+	if len(comp.Headers) == 0 {
+		e = multierror.Append(e, errors.New("missing: headers"))
 	}
-	if len(cfg.VegaAssets) == 0 {
+	if len(comp.VegaAssets) == 0 {
 		e = multierror.Append(e, errors.New("missing: vegaAssets"))
 	}
+	if comp.StartTime.Before(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		e = multierror.Append(e, errors.New("missing/invalid: startTime"))
+	}
+	if comp.EndTime.Before(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		e = multierror.Append(e, errors.New("missing/invalid: endTime"))
+	}
+	if comp.ReferencePeriod < 0 {
+		e = multierror.Append(e, errors.New("invalid: referencePeriod (should not be negative)"))
+	}
+	for name := range comp.Baselines {
+		if !competitionIDPattern.MatchString(name) {
+			e = multierror.Append(e, fmt.Errorf("invalid: baselines name %q (letters, digits, '-' and '_' only)", name))
+		}
+	}
+
+	return e.ErrorOrNil()
+}
+
+// CheckExclusion checks a single exclusion, e.g. one added through the admin API.
+func CheckExclusion(exclusion Exclusion) error {
+	var e *multierror.Error
+
+	set := 0
+	for _, field := range []string{exclusion.PublicKey, exclusion.UserID, exclusion.Handle} {
+		if len(field) > 0 {
+			set++
+		}
+	}
+	if set != 1 {
+		e = multierror.Append(e, errors.New("invalid: exactly one of publicKey, userID or handle is required"))
+	}
+	if len(exclusion.PublicKey) > 0 && len(exclusion.Provider) > 0 {
+		e = multierror.Append(e, errors.New("invalid: provider does not apply to publicKey"))
+	}
+	if _, err := path.Match(exclusion.Handle, ""); err != nil {
+		e = multierror.Append(e, fmt.Errorf("invalid: handle pattern %q", exclusion.Handle))
+	}
+	if len(exclusion.Reason) == 0 {
+		e = multierror.Append(e, errors.New("missing: reason"))
+	}
+
+	return e.ErrorOrNil()
+}
+
+// AlgorithmValidator checks an algorithm name and its algorithm-specific config, and that
+// defaultSort names one of the algorithm's metrics.
+// The leaderboard package provides an implementation backed by its algorithm registry.
+type AlgorithmValidator interface {
+	ValidateAlgorithm(name string, algorithmConfig map[string]string) error
+	ValidateSort(name string, defaultSort string) error
+}
+
+func CheckConfig(cfg Config, algorithms AlgorithmValidator) error {
+	var e *multierror.Error
+
+	if len(cfg.Listen) == 0 {
+		e = multierror.Append(e, errors.New("missing: listen"))
+	}
+	if len(cfg.LogFormat) == 0 {
+		e = multierror.Append(e, errors.New("missing: logFormat"))
+	}
+	if len(cfg.LogLevel) == 0 {
+		e = multierror.Append(e, errors.New("missing: logLevel"))
+	}
+	if cfg.GracefulShutdownTimeout <= 0 {
+		e = multierror.Append(e, errors.New("invalid: gracefulShutdownTimeout (should be greater than 0)"))
+	}
+	if !cfg.HasSocialURL() && len(cfg.SocialFile) == 0 && len(cfg.SocialCollectionName) == 0 && len(cfg.Socials) == 0 {
+		e = multierror.Append(e, errors.New("missing: socialURL (or socialFile, socialCollectionName, socials)"))
+	}
+	for i, social := range cfg.Socials {
+		if len(social.PartyID) == 0 {
+			e = multierror.Append(e, fmt.Errorf("missing: socials[%d].partyID", i))
+		}
+	}
 	if cfg.VegaGraphQLURL == nil || cfg.VegaGraphQLURL.String() == "" {
 		e = multierror.Append(e, errors.New("missing: vegaGraphQLURL"))
 	}
+	for i, u := range cfg.VegaGraphQLURLs {
+		if u == nil || u.String() == "" {
+			e = multierror.Append(e, fmt.Errorf("missing: vegaGraphQLURLs[%d]", i))
+		}
+	}
+	if cfg.VegaRequestTimeout < 0 {
+		e = multierror.Append(e, errors.New("invalid: vegaRequestTimeout (should not be negative)"))
+	}
+	if cfg.VegaRetries < 0 {
+		e = multierror.Append(e, errors.New("invalid: vegaRetries (should not be negative)"))
+	}
+	if cfg.MongoTimeout < 0 {
+		e = multierror.Append(e, errors.New("invalid: mongoTimeout (should not be negative)"))
+	}
+	if cfg.SnapshotRetention < 0 {
+		e = multierror.Append(e, errors.New("invalid: snapshotRetention (should not be negative)"))
+	}
 	if cfg.VegaPoll <= 0 {
 		e = multierror.Append(e, errors.New("invalid: vegaPoll (should be greater than 0)"))
 	}
-	if cfg.StartTime.Before(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)) {
-		e = multierror.Append(e, errors.New("missing/invalid: startTime"))
+	for i, exclusion := range cfg.Exclusions {
+		if err := CheckExclusion(exclusion); err != nil {
+			e = multierror.Append(e, errors.Wrapf(err, "invalid exclusions[%d]", i))
+		}
 	}
-	if cfg.EndTime.Before(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)) {
-		e = multierror.Append(e, errors.New("missing/invalid: endTime"))
+	seen := map[string]bool{}
+	for _, comp := range cfg.CompetitionList() {
+		if seen[comp.ID] {
+			e = multierror.Append(e, fmt.Errorf("duplicate: competition id %s", comp.ID))
+		}
+		seen[comp.ID] = true
+		if err := checkCompetition(comp, algorithms); err != nil {
+			e = multierror.Append(e, errors.Wrapf(err, "invalid competition %s", comp.ID))
+		}
+		if length := comp.EndTime.Sub(comp.StartTime); cfg.SnapshotRetention > 0 && cfg.SnapshotRetention <= length {
+			e = multierror.Append(e, fmt.Errorf("invalid: snapshotRetention (should be longer than competition %s, %s)", comp.ID, length))
+		}
 	}
 	if len(cfg.MongoConnectionString) == 0 {
 		e = multierror.Append(e, errors.New("missing: mongoConnectionString"))
@@ -117,13 +412,26 @@ func CheckConfig(cfg Config) error {
 	if len(cfg.MongoConnectionString) > 0 && len(cfg.MongoCollectionName) == 0 {
 		e = multierror.Append(e, errors.New("missing: mongoCollectionName"))
 	}
-	if len(cfg.MongoDatabaseName) > 0 && len(cfg.MongoDatabaseName) == 0 {
+	if len(cfg.MongoConnectionString) > 0 && len(cfg.MongoDatabaseName) == 0 {
 		e = multierror.Append(e, errors.New("missing: mongoDatabaseName"))
 	}
 
 	return e.ErrorOrNil()
 }
 
+// HasSocialURL returns true if the social media verification API is configured.
+func (c *Config) HasSocialURL() bool {
+	return c.socialURL() != ""
+}
+
+// socialURL returns SocialURL as a string, which is optional when other socials are configured.
+func (c *Config) socialURL() string {
+	if c.SocialURL == nil {
+		return ""
+	}
+	return c.SocialURL.String()
+}
+
 func (c *Config) String() string {
 	fmtStr := "Config{ " +
 		"listen:%s, " +
@@ -133,17 +441,30 @@ func (c *Config) String() string {
 		"gracefulShutdownTimeout:%s, " +
 		"headers:%v" +
 		"socialURL:%s, " +
+		"socialFile:%s, " +
+		"socialCollectionName:%s, " +
+		"socials:%d, " +
 		"vegaAssets:%v, " +
 		"marketIDs:%v, " +
 		"vegaGraphQLURL:%s, " +
+		"vegaGraphQLURLs:%v, " +
+		"vegaRequestTimeout:%s, " +
+		"vegaRetries:%d, " +
 		"vegaPoll:%s" +
 		"startTime:%s" +
 		"endTime:%s" +
 		"mongoConnectionString:%s" +
 		"mongoCollectionName:%s" +
 		"mongoDatabaseName:%s" +
+		"mongoTimeout:%s" +
 		"snapshotEnabled:%v" +
+		"snapshotRetention:%s" +
+		"baselineDir:%s" +
+		"baselines:%v" +
+		"referencePeriod:%s" +
+		"exclusions:%d" +
 		"twitterBlacklist:%v" +
+		"competitions:%d" +
 		"}"
 	return fmt.Sprintf(
 		fmtStr,
@@ -153,46 +474,76 @@ func (c *Config) String() string {
 		c.Description,
 		c.GracefulShutdownTimeout,
 		c.Headers,
-		c.SocialURL.String(),
+		c.socialURL(),
+		c.SocialFile,
+		c.SocialCollectionName,
+		len(c.Socials),
 		c.VegaAssets,
 		c.MarketIDs,
 		c.VegaGraphQLURL.String(),
+		c.VegaGraphQLURLs,
+		c.VegaRequestTimeout,
+		c.VegaRetries,
 		c.VegaPoll.String(),
 		c.StartTime,
 		c.EndTime,
 		c.MongoConnectionString,
 		c.MongoCollectionName,
 		c.MongoDatabaseName,
+		c.MongoTimeout,
 		c.SnapshotEnabled,
+		c.SnapshotRetention,
+		c.BaselineDir,
+		c.Baselines,
+		c.ReferencePeriod,
+		len(c.Exclusions),
 		c.TwitterBlacklist,
+		len(c.Competitions),
 	)
 }
 
 func (c *Config) LogFields() log.Fields {
 	return log.Fields{
 		"listen":                  c.Listen,
+		"adminEnabled":            len(c.AdminToken) > 0,
 		"logFormat":               c.LogFormat,
 		"logLevel":                c.LogLevel,
 		"logMethodName":           c.LogMethodName,
+		"id":                      c.ID,
 		"algorithm":               c.Algorithm,
 		"algorithmConfig":         c.AlgorithmConfig,
 		"description":             c.Description,
 		"defaultDisplay":          c.DefaultDisplay,
 		"defaultSort":             c.DefaultSort,
+		"tieBreak":                c.TieBreak,
+		"ranking":                 c.Ranking,
 		"gracefulShutdownTimeout": c.GracefulShutdownTimeout,
 		"headers":                 c.Headers,
-		"socialURL":               c.SocialURL.String(),
+		"socialURL":               c.socialURL(),
+		"socialFile":              c.SocialFile,
+		"socialCollectionName":    c.SocialCollectionName,
+		"socials":                 len(c.Socials),
 		"vegaAssets":              c.VegaAssets,
 		"marketIDs":               c.MarketIDs,
 		"vegaGraphQLURL":          c.VegaGraphQLURL.String(),
+		"vegaGraphQLURLs":         c.VegaGraphQLURLs,
+		"vegaRequestTimeout":      c.VegaRequestTimeout,
+		"vegaRetries":             c.VegaRetries,
 		"vegaPoll":                c.VegaPoll.String(),
 		"startTime":               c.StartTime,
 		"endTime":                 c.EndTime,
 		"mongoConnectionString":   c.MongoConnectionString,
 		"mongoCollectionName":     c.MongoCollectionName,
 		"mongoDatabaseName":       c.MongoDatabaseName,
+		"mongoTimeout":            c.MongoTimeout.String(),
 		"snapshotEnabled":         c.SnapshotEnabled,
+		"snapshotRetention":       c.SnapshotRetention.String(),
+		"baselineDir":             c.BaselineDir,
+		"baselines":               c.Baselines,
+		"referencePeriod":         c.ReferencePeriod.String(),
+		"exclusions":              len(c.Exclusions),
 		"twitterBlacklist":        c.TwitterBlacklist,
+		"competitions":            len(c.Competitions),
 	}
 }
 
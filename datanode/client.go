@@ -0,0 +1,311 @@
+// Package datanode is a GraphQL client for a set of Vega data nodes. Requests are retried
+// with backoff, fail over to the next healthy node, and a node that keeps failing is skipped
+// for a cooldown period by its circuit breaker.
+package datanode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/metrics"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrUnavailable is returned when the circuit breakers of all data nodes are open.
+var ErrUnavailable = errors.New("no data node available")
+
+// Config describes the data nodes and how hard to try them. Zero values are replaced by the
+// values of DefaultConfig.
+type Config struct {
+	// URLs are the GraphQL endpoints of the data nodes, in order of preference.
+	URLs []url.URL
+
+	// Timeout is the timeout of a single request to a data node.
+	Timeout time.Duration
+
+	// Retries is the number of times a failed request is tried again, on any node.
+	Retries int
+
+	// Backoff is the wait before the first retry. It doubles for every retry, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// FailureThreshold is the number of consecutive failures that opens a node's circuit
+	// breaker. The node is then skipped until Cooldown has passed.
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// DefaultConfig holds the defaults for the optional fields of Config.
+var DefaultConfig = Config{
+	Timeout:          30 * time.Second,
+	Retries:          3,
+	Backoff:          500 * time.Millisecond,
+	MaxBackoff:       10 * time.Second,
+	FailureThreshold: 5,
+	Cooldown:         time.Minute,
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultConfig.Timeout
+	}
+	if cfg.Retries <= 0 {
+		cfg.Retries = DefaultConfig.Retries
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = DefaultConfig.Backoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultConfig.MaxBackoff
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = DefaultConfig.FailureThreshold
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = DefaultConfig.Cooldown
+	}
+	return cfg
+}
+
+// QueryError is an error reported by the GraphQL API in a successful response, e.g. an
+// invalid query. It is not retried, as every node would report the same.
+type QueryError struct {
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	return "graphql: " + e.Message
+}
+
+// Client sends GraphQL requests to the configured data nodes. It is safe for concurrent use.
+type Client struct {
+	cfg   Config
+	http  *http.Client
+	nodes []*node
+}
+
+// node is a data node and the state of its circuit breaker.
+type node struct {
+	url url.URL
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// NewClient creates a client for the data nodes in cfg.
+func NewClient(cfg Config) *Client {
+	cfg = cfg.withDefaults()
+	c := &Client{
+		cfg:  cfg,
+		http: &http.Client{Timeout: cfg.Timeout},
+	}
+	for _, u := range cfg.URLs {
+		c.nodes = append(c.nodes, &node{url: u})
+	}
+	return c
+}
+
+// Run sends a GraphQL query with its variables and decodes the data of the response into
+// response. Failed requests are retried, on another node if one is available, until the
+// retries are used up or ctx is cancelled.
+func (c *Client) Run(ctx context.Context, query string, vars map[string]interface{}, response interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": vars})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode request")
+	}
+
+	tried := map[*node]bool{}
+	var lastErr error
+	for attempt := 0; attempt <= c.cfg.Retries; attempt++ {
+		if attempt > 0 {
+			if err := c.wait(ctx, attempt); err != nil {
+				return err
+			}
+		}
+		n := c.pick(tried)
+		if n == nil {
+			if lastErr != nil {
+				return errors.Wrap(ErrUnavailable, lastErr.Error())
+			}
+			return ErrUnavailable
+		}
+		tried[n] = true
+
+		start := time.Now()
+		err := c.send(ctx, n, body, response)
+		if err == nil {
+			metrics.ObserveGraphQLRequest(query, time.Since(start), metrics.ResultSuccess)
+			n.succeeded()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var queryErr *QueryError
+		if errors.As(err, &queryErr) {
+			// The node is healthy, the query is not
+			metrics.ObserveGraphQLRequest(query, time.Since(start), metrics.ResultQueryError)
+			n.succeeded()
+			return err
+		}
+		metrics.ObserveGraphQLRequest(query, time.Since(start), metrics.ResultError)
+		if n.failed(c.cfg.FailureThreshold, c.cfg.Cooldown) {
+			log.WithFields(log.Fields{"url": n.url.String(), "cooldown": c.cfg.Cooldown}).Warn("Data node circuit breaker opened")
+		}
+		log.WithError(err).WithFields(log.Fields{"url": n.url.String(), "attempt": attempt + 1}).Warn("Data node request failed")
+		lastErr = err
+	}
+	return errors.Wrapf(lastErr, "data node request failed after %d attempts", c.cfg.Retries+1)
+}
+
+// Available returns true if the circuit breaker of at least one data node is closed, so
+// requests are being sent.
+func (c *Client) Available() bool {
+	now := time.Now()
+	for _, n := range c.nodes {
+		if n.available(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// pingQuery is the cheapest query every GraphQL API answers.
+const pingQuery = "{ __typename }"
+
+// Ping sends a trivial query to each available data node in turn, without retries, and
+// returns nil as soon as one answers. It does not change the state of the circuit breakers,
+// so it can be used by health checks.
+func (c *Client) Ping(ctx context.Context) error {
+	body, err := json.Marshal(map[string]interface{}{"query": pingQuery})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode request")
+	}
+	now := time.Now()
+	lastErr := ErrUnavailable
+	for _, n := range c.nodes {
+		if !n.available(now) {
+			continue
+		}
+		var response struct{}
+		err := c.send(ctx, n, body, &response)
+		var queryErr *QueryError
+		if err == nil || errors.As(err, &queryErr) {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		lastErr = errors.Wrap(err, n.url.String())
+	}
+	return lastErr
+}
+
+// pick returns the first available node not yet tried for a request, or else the first
+// available node. It returns nil if every node's circuit breaker is open.
+func (c *Client) pick(tried map[*node]bool) *node {
+	now := time.Now()
+	var fallback *node
+	for _, n := range c.nodes {
+		if !n.available(now) {
+			continue
+		}
+		if !tried[n] {
+			return n
+		}
+		if fallback == nil {
+			fallback = n
+		}
+	}
+	return fallback
+}
+
+// wait sleeps for the backoff before a retry, or until ctx is cancelled.
+func (c *Client) wait(ctx context.Context, attempt int) error {
+	backoff := c.cfg.Backoff << (attempt - 1)
+	if backoff <= 0 || backoff > c.cfg.MaxBackoff {
+		backoff = c.cfg.MaxBackoff
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (c *Client) send(ctx context.Context, n *node, body []byte, response interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Accept", "application/json; charset=utf-8")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	payload, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("data node returned status %d", resp.StatusCode)
+	}
+
+	result := struct {
+		Data   interface{} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{Data: response}
+	if err := json.Unmarshal(payload, &result); err != nil {
+		return errors.Wrap(err, "failed to decode response")
+	}
+	if len(result.Errors) > 0 {
+		return &QueryError{Message: result.Errors[0].Message}
+	}
+	return nil
+}
+
+func (n *node) available(now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return !now.Before(n.openUntil)
+}
+
+func (n *node) succeeded() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.failures = 0
+	n.openUntil = time.Time{}
+}
+
+// failed records a failure and returns true if it opened the circuit breaker. After the
+// cooldown one request is let through, and the breaker opens again if it fails too.
+func (n *node) failed(threshold int, cooldown time.Duration) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.failures++
+	if n.failures < threshold {
+		return false
+	}
+	n.openUntil = time.Now().Add(cooldown)
+	return true
+}
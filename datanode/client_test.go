@@ -0,0 +1,156 @@
+package datanode_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/datanode"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testResponse struct {
+	Node string `json:"node"`
+}
+
+// newTestNode serves a data node that fails with 503 while fail returns true.
+func newTestNode(t *testing.T, name string, fail func(call int32) bool) (url.URL, *int32) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := atomic.AddInt32(&calls, 1)
+		if fail(call) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"node": "` + name + `"}}`))
+	}))
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	return *u, &calls
+}
+
+func testConfig(urls ...url.URL) datanode.Config {
+	return datanode.Config{
+		URLs:             urls,
+		Timeout:          time.Second,
+		Retries:          3,
+		Backoff:          time.Millisecond,
+		MaxBackoff:       5 * time.Millisecond,
+		FailureThreshold: 2,
+		Cooldown:         time.Hour,
+	}
+}
+
+func never(int32) bool  { return false }
+func always(int32) bool { return true }
+
+func TestRunRetriesFailedRequests(t *testing.T) {
+	primary, calls := newTestNode(t, "primary", func(call int32) bool { return call == 1 })
+	client := datanode.NewClient(testConfig(primary))
+
+	var resp testResponse
+	require.NoError(t, client.Run(context.Background(), "{ node }", nil, &resp))
+	require.Equal(t, "primary", resp.Node)
+	require.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestRunFailsOverAndOpensCircuitBreaker(t *testing.T) {
+	primary, primaryCalls := newTestNode(t, "primary", always)
+	secondary, _ := newTestNode(t, "secondary", never)
+	client := datanode.NewClient(testConfig(primary, secondary))
+
+	for i := 0; i < 3; i++ {
+		var resp testResponse
+		require.NoError(t, client.Run(context.Background(), "{ node }", nil, &resp))
+		require.Equal(t, "secondary", resp.Node)
+	}
+	// The primary is skipped once it has failed FailureThreshold times
+	require.Equal(t, int32(2), atomic.LoadInt32(primaryCalls))
+}
+
+func TestRunFailsFastWhenAllCircuitsAreOpen(t *testing.T) {
+	primary, calls := newTestNode(t, "primary", always)
+	client := datanode.NewClient(testConfig(primary))
+
+	var resp testResponse
+	err := client.Run(context.Background(), "{ node }", nil, &resp)
+	require.True(t, errors.Is(err, datanode.ErrUnavailable), err)
+	require.Equal(t, int32(2), atomic.LoadInt32(calls))
+
+	err = client.Run(context.Background(), "{ node }", nil, &resp)
+	require.True(t, errors.Is(err, datanode.ErrUnavailable), err)
+	require.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestRunDoesNotRetryQueryErrors(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"errors": [{"message": "Cannot query field \"nope\""}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	client := datanode.NewClient(testConfig(*u))
+
+	var resp testResponse
+	err = client.Run(context.Background(), "{ nope }", nil, &resp)
+	var queryErr *datanode.QueryError
+	require.True(t, errors.As(err, &queryErr), err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRunStopsWhenCancelled(t *testing.T) {
+	primary, _ := newTestNode(t, "primary", always)
+	cfg := testConfig(primary)
+	cfg.Backoff = time.Hour
+	cfg.MaxBackoff = time.Hour
+	client := datanode.NewClient(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	var resp testResponse
+	err := client.Run(ctx, "{ node }", nil, &resp)
+	require.True(t, errors.Is(err, context.Canceled), err)
+}
+
+func TestPingTriesEachNodeOnce(t *testing.T) {
+	primary, primaryCalls := newTestNode(t, "primary", always)
+	secondary, secondaryCalls := newTestNode(t, "secondary", never)
+	client := datanode.NewClient(testConfig(primary, secondary))
+
+	require.NoError(t, client.Ping(context.Background()))
+	require.Equal(t, int32(1), atomic.LoadInt32(primaryCalls))
+	require.Equal(t, int32(1), atomic.LoadInt32(secondaryCalls))
+
+	// A failed ping does not open the circuit breakers
+	client = datanode.NewClient(testConfig(primary))
+	require.Error(t, client.Ping(context.Background()))
+	require.Error(t, client.Ping(context.Background()))
+	require.True(t, client.Available())
+}
+
+func TestPingStopsAtDeadline(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	client := datanode.NewClient(testConfig(*u))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = client.Ping(ctx)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err)
+}
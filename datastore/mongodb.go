@@ -3,40 +3,60 @@ package datastore
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// DefaultTimeout bounds each MongoDB operation when no timeout is configured.
+const DefaultTimeout = 10 * time.Second
+
 type Service struct {
 	mu          sync.RWMutex
 	cli         *mongo.Client
 	ctx         context.Context
 	connStr     string
+	timeout     time.Duration
 	isConnected bool
 }
 
-func NewMongoDbDatastore(ctx context.Context, connStr string) *Service {
+// NewMongoDbDatastore returns a datastore whose operations each time out after timeout.
+// A timeout of zero or less uses DefaultTimeout.
+func NewMongoDbDatastore(ctx context.Context, connStr string, timeout time.Duration) *Service {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
 	s := Service{
 		ctx:     ctx,
 		connStr: connStr,
+		timeout: timeout,
 	}
 	return &s
 }
 
+// opContext returns the context for a single operation, so that an unresponsive MongoDB
+// instance fails the operation instead of blocking its caller.
+func (s *Service) opContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(s.ctx, s.timeout)
+}
+
 func (s *Service) Connect() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.isConnected {
 		return errors.New("MongoDB instance already connected")
 	}
-	cli, err := mongo.Connect(s.ctx, options.Client().ApplyURI(s.connStr))
+	ctx, cancel := s.opContext()
+	defer cancel()
+	cli, err := mongo.Connect(ctx, options.Client().ApplyURI(s.connStr))
 	if err != nil {
 		return errors.Wrap(err, "Could not connect to MongoDB instance")
 	}
-	err = cli.Ping(s.ctx, nil)
+	err = cli.Ping(ctx, nil)
 	if err != nil {
 		return errors.Wrap(err, "Could not ping the MongoDB instance")
 	}
@@ -52,13 +72,113 @@ func (s *Service) LoadDocumentCollection(databaseName string, collectionName str
 	return collection
 }
 
+// IsConnected returns true between a successful Connect and Disconnect.
+func (s *Service) IsConnected() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.isConnected
+}
+
+// Ping checks that the MongoDB instance is reachable.
+func (s *Service) Ping(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.isConnected {
+		return errors.New("MongoDB instance not connected")
+	}
+	return s.cli.Ping(ctx, nil)
+}
+
+// InsertDocument adds a single document to a collection.
+func (s *Service) InsertDocument(databaseName string, collectionName string, document interface{}) error {
+	if !s.IsConnected() {
+		return errors.New("MongoDB instance not connected")
+	}
+	ctx, cancel := s.opContext()
+	defer cancel()
+	_, err := s.LoadDocumentCollection(databaseName, collectionName).InsertOne(ctx, document)
+	if err != nil {
+		return errors.Wrap(err, "Could not insert document")
+	}
+	return nil
+}
+
+// FindOneDocument decodes into result the first document matching filter, in the given sort order.
+// It returns false if no document matches.
+func (s *Service) FindOneDocument(databaseName string, collectionName string, filter bson.D, sort bson.D, result interface{}) (bool, error) {
+	if !s.IsConnected() {
+		return false, errors.New("MongoDB instance not connected")
+	}
+	opts := options.FindOne()
+	if len(sort) > 0 {
+		opts.SetSort(sort)
+	}
+	ctx, cancel := s.opContext()
+	defer cancel()
+	err := s.LoadDocumentCollection(databaseName, collectionName).FindOne(ctx, filter, opts).Decode(result)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "Could not find document")
+	}
+	return true, nil
+}
+
+// FindDocuments decodes into results (a pointer to a slice) every document matching filter,
+// in the given sort order. A projection limits the fields returned, it may be nil.
+func (s *Service) FindDocuments(databaseName string, collectionName string, filter bson.D, sort bson.D, projection bson.D, results interface{}) error {
+	if !s.IsConnected() {
+		return errors.New("MongoDB instance not connected")
+	}
+	opts := options.Find()
+	if len(sort) > 0 {
+		opts.SetSort(sort)
+	}
+	if len(projection) > 0 {
+		opts.SetProjection(projection)
+	}
+	ctx, cancel := s.opContext()
+	defer cancel()
+	cursor, err := s.LoadDocumentCollection(databaseName, collectionName).Find(ctx, filter, opts)
+	if err != nil {
+		return errors.Wrap(err, "Could not find documents")
+	}
+	if err := cursor.All(ctx, results); err != nil {
+		return errors.Wrap(err, "Could not decode documents")
+	}
+	return nil
+}
+
+// CreateIndex creates an index on the keys of a collection, unless an identical one exists.
+// A positive expireAfter makes it a TTL index: MongoDB then deletes documents once the time
+// in the indexed field is older than expireAfter. TTL indexes must have a single time key.
+func (s *Service) CreateIndex(databaseName string, collectionName string, keys bson.D, expireAfter time.Duration) error {
+	if !s.IsConnected() {
+		return errors.New("MongoDB instance not connected")
+	}
+	opts := options.Index()
+	if expireAfter > 0 {
+		opts.SetExpireAfterSeconds(int32(expireAfter.Seconds()))
+	}
+	ctx, cancel := s.opContext()
+	defer cancel()
+	_, err := s.LoadDocumentCollection(databaseName, collectionName).Indexes().CreateOne(ctx, mongo.IndexModel{Keys: keys, Options: opts})
+	if err != nil {
+		return errors.Wrap(err, "Could not create index")
+	}
+	return nil
+}
+
 func (s *Service) Disconnect() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.cli == nil || !s.isConnected {
 		return nil
 	}
-	err := s.cli.Disconnect(s.ctx)
+	ctx, cancel := s.opContext()
+	defer cancel()
+	err := s.cli.Disconnect(ctx)
 	s.isConnected = false
 	log.Info("Disconnected from MongoDB instance")
 	return err
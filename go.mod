@@ -10,9 +10,9 @@ require (
 	github.com/gorilla/mux v1.7.4
 	github.com/hashicorp/go-multierror v1.1.1
 	github.com/jinzhu/configor v1.2.1
-	github.com/machinebox/graphql v0.2.2
-	github.com/matryer/is v1.3.0 // indirect
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.12.2
+	github.com/shopspring/decimal v1.3.1
 	github.com/sirupsen/logrus v1.7.0
 	github.com/stretchr/objx v0.1.1 // indirect
 	github.com/stretchr/testify v1.7.0
@@ -0,0 +1,255 @@
+package leaderboard
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/datastore"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	// ErrCompetitionNotFound is returned for an unknown competition ID.
+	ErrCompetitionNotFound = errors.New("competition not found")
+	// ErrExclusionNotFound is returned for an unknown exclusion ID.
+	ErrExclusionNotFound = errors.New("exclusion not found")
+	// ErrInvalidExclusion wraps the validation errors of an exclusion.
+	ErrInvalidExclusion = errors.New("invalid exclusion")
+)
+
+// Exclusion is an exclusion added through the admin API. It applies to every competition.
+type Exclusion struct {
+	ID        string     `json:"id" bson:"id"`
+	PublicKey string     `json:"publicKey,omitempty" bson:"public_key,omitempty"`
+	Provider  string     `json:"provider,omitempty" bson:"provider,omitempty"`
+	UserID    string     `json:"userId,omitempty" bson:"user_id,omitempty"`
+	Handle    string     `json:"handle,omitempty" bson:"handle,omitempty"`
+	Reason    string     `json:"reason" bson:"reason"`
+	Expires   *time.Time `json:"expires,omitempty" bson:"expires,omitempty"`
+	CreatedAt time.Time  `json:"createdAt" bson:"created_at"`
+}
+
+func (e Exclusion) config() config.Exclusion {
+	exclusion := config.Exclusion{
+		PublicKey: e.PublicKey,
+		Provider:  e.Provider,
+		UserID:    e.UserID,
+		Handle:    e.Handle,
+		Reason:    e.Reason,
+	}
+	if e.Expires != nil {
+		exclusion.Expires = *e.Expires
+	}
+	return exclusion
+}
+
+// AdminState is the runtime configuration set through the admin API.
+type AdminState struct {
+	Timestamp  time.Time   `bson:"timestamp"`
+	Exclusions []Exclusion `bson:"exclusions"`
+	// Paused lists the IDs of the competitions whose updates are paused
+	Paused []string `bson:"paused"`
+}
+
+// AdminStore persists the admin state, so it survives restarts.
+type AdminStore interface {
+	// SaveAdminState stores a new version of the state.
+	SaveAdminState(state AdminState) error
+
+	// LatestAdminState returns the last saved state, or nil if there is none.
+	LatestAdminState() (*AdminState, error)
+}
+
+// MongoAdminStore keeps every version of the admin state in a MongoDB collection, as an audit trail.
+type MongoAdminStore struct {
+	ds             *datastore.Service
+	databaseName   string
+	collectionName string
+}
+
+// NewMongoAdminStore creates an AdminStore using a connected datastore.
+func NewMongoAdminStore(ds *datastore.Service, databaseName string, collectionName string) *MongoAdminStore {
+	return &MongoAdminStore{
+		ds:             ds,
+		databaseName:   databaseName,
+		collectionName: collectionName,
+	}
+}
+
+func (m *MongoAdminStore) SaveAdminState(state AdminState) error {
+	return m.ds.InsertDocument(m.databaseName, m.collectionName, state)
+}
+
+func (m *MongoAdminStore) LatestAdminState() (*AdminState, error) {
+	var state AdminState
+	found, err := m.ds.FindOneDocument(m.databaseName, m.collectionName, bson.D{}, bson.D{{Key: "timestamp", Value: -1}}, &state)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Admin changes the exclusions and updates of the hosted competitions at runtime. Every
+// change is saved to the store, if there is one, and restored by NewAdmin after a restart.
+type Admin struct {
+	host  *Host
+	store AdminStore
+
+	mu    sync.Mutex
+	state AdminState
+}
+
+// NewAdmin restores the last saved admin state into the host's competitions. It must be
+// called before the host is started, so the first updates use the restored exclusions.
+func NewAdmin(host *Host, store AdminStore) *Admin {
+	a := &Admin{host: host, store: store}
+	if store != nil {
+		state, err := store.LatestAdminState()
+		if err != nil {
+			log.WithError(err).Warn("Failed to load admin state")
+		} else if state != nil {
+			a.state = *state
+			log.WithFields(log.Fields{
+				"exclusions": len(state.Exclusions),
+				"paused":     state.Paused,
+			}).Info("Restored admin state")
+		}
+	}
+	a.apply()
+	return a
+}
+
+// Exclusions returns the exclusions added through the admin API, oldest first.
+func (a *Admin) Exclusions() []Exclusion {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Exclusion{}, a.state.Exclusions...)
+}
+
+// AddExclusion validates and adds an exclusion, which applies from the next update.
+func (a *Admin) AddExclusion(exclusion Exclusion) (Exclusion, error) {
+	if err := config.CheckExclusion(exclusion.config()); err != nil {
+		return Exclusion{}, fmt.Errorf("%w: %v", ErrInvalidExclusion, err)
+	}
+	id, err := newExclusionID()
+	if err != nil {
+		return Exclusion{}, err
+	}
+	exclusion.ID = id
+	exclusion.CreatedAt = time.Now().UTC()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	next := a.state
+	next.Exclusions = append(append([]Exclusion{}, a.state.Exclusions...), exclusion)
+	if err := a.save(next); err != nil {
+		return Exclusion{}, err
+	}
+	return exclusion, nil
+}
+
+// RemoveExclusion removes an exclusion added through the admin API.
+func (a *Admin) RemoveExclusion(id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	next := a.state
+	next.Exclusions = []Exclusion{}
+	for _, exclusion := range a.state.Exclusions {
+		if exclusion.ID != id {
+			next.Exclusions = append(next.Exclusions, exclusion)
+		}
+	}
+	if len(next.Exclusions) == len(a.state.Exclusions) {
+		return ErrExclusionNotFound
+	}
+	return a.save(next)
+}
+
+// Pause pauses the updates of a competition.
+func (a *Admin) Pause(competition string) error {
+	return a.setPaused(competition, true)
+}
+
+// Resume resumes the updates of a competition.
+func (a *Admin) Resume(competition string) error {
+	return a.setPaused(competition, false)
+}
+
+// Recompute starts an update of a competition now, even while its updates are paused.
+func (a *Admin) Recompute(competition string) error {
+	svc, found := a.host.Get(competition)
+	if !found {
+		return ErrCompetitionNotFound
+	}
+	svc.Recompute()
+	return nil
+}
+
+func (a *Admin) setPaused(competition string, paused bool) error {
+	if _, found := a.host.Get(competition); !found {
+		return ErrCompetitionNotFound
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	next := a.state
+	next.Paused = []string{}
+	for _, id := range a.state.Paused {
+		if id != competition {
+			next.Paused = append(next.Paused, id)
+		}
+	}
+	if paused {
+		next.Paused = append(next.Paused, competition)
+		sort.Strings(next.Paused)
+	}
+	return a.save(next)
+}
+
+// save persists the next state and then applies it. The caller holds mu.
+func (a *Admin) save(next AdminState) error {
+	next.Timestamp = time.Now().UTC()
+	if a.store != nil {
+		if err := a.store.SaveAdminState(next); err != nil {
+			return err
+		}
+	}
+	a.state = next
+	a.apply()
+	return nil
+}
+
+// apply sets the runtime exclusions and paused state of every competition.
+func (a *Admin) apply() {
+	exclusions := make([]config.Exclusion, 0, len(a.state.Exclusions))
+	for _, exclusion := range a.state.Exclusions {
+		exclusions = append(exclusions, exclusion.config())
+	}
+	paused := map[string]bool{}
+	for _, id := range a.state.Paused {
+		paused[id] = true
+	}
+	for _, svc := range a.host.Services() {
+		svc.SetRuntimeExclusions(exclusions)
+		if paused[svc.ID()] {
+			svc.Pause()
+		} else {
+			svc.Resume()
+		}
+	}
+}
+
+func newExclusionID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,83 @@
+package leaderboard_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryAdminStore struct {
+	mu     sync.Mutex
+	states []leaderboard.AdminState
+}
+
+func (m *memoryAdminStore) SaveAdminState(state leaderboard.AdminState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states = append(m.states, state)
+	return nil
+}
+
+func (m *memoryAdminStore) LatestAdminState() (*leaderboard.AdminState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.states) == 0 {
+		return nil, nil
+	}
+	state := m.states[len(m.states)-1]
+	return &state, nil
+}
+
+func TestAdminExclusionsApplyWithoutRestart(t *testing.T) {
+	store := &memoryAdminStore{}
+	host := leaderboard.NewHost(newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"}))
+	admin := leaderboard.NewAdmin(host, store)
+	host.Start()
+	defer host.Stop()
+	require.Equal(t, []string{"p2", "p1"}, publicKeys(currentBoard(t, host.Default())))
+
+	_, err := admin.AddExclusion(leaderboard.Exclusion{PublicKey: "p2"})
+	require.True(t, errors.Is(err, leaderboard.ErrInvalidExclusion), err)
+
+	added, err := admin.AddExclusion(leaderboard.Exclusion{PublicKey: "p2", Reason: "wash trading"})
+	require.NoError(t, err)
+	require.NotEmpty(t, added.ID)
+	require.Len(t, store.states, 1)
+
+	require.NoError(t, admin.Recompute("default"))
+	require.Eventually(t, func() bool {
+		return len(currentBoard(t, host.Default()).Participants) == 1
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, []string{"p1"}, publicKeys(currentBoard(t, host.Default())))
+
+	require.Equal(t, leaderboard.ErrExclusionNotFound, admin.RemoveExclusion("unknown"))
+	require.NoError(t, admin.RemoveExclusion(added.ID))
+	require.Empty(t, admin.Exclusions())
+	require.Equal(t, leaderboard.ErrCompetitionNotFound, admin.Recompute("unknown"))
+}
+
+func TestAdminStateIsRestored(t *testing.T) {
+	store := &memoryAdminStore{}
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	admin := leaderboard.NewAdmin(leaderboard.NewHost(cfg), store)
+	_, err := admin.AddExclusion(leaderboard.Exclusion{Handle: "two", Reason: "team member"})
+	require.NoError(t, err)
+	require.NoError(t, admin.Pause("default"))
+
+	// A restarted host picks up the exclusion and the pause from the store
+	host := leaderboard.NewHost(cfg)
+	restored := leaderboard.NewAdmin(host, store)
+	require.Len(t, restored.Exclusions(), 1)
+	require.True(t, host.Default().Paused())
+	require.True(t, host.Summaries()[0].Paused)
+
+	require.NoError(t, restored.Resume("default"))
+	host.Start()
+	defer host.Stop()
+	require.Equal(t, []string{"p1"}, publicKeys(currentBoard(t, host.Default())))
+}
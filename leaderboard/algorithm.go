@@ -0,0 +1,142 @@
+package leaderboard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vegaprotocol/topgun-service/verifier"
+)
+
+// Algorithm describes a method of scoring and ordering participants.
+// Algorithms add themselves to the registry with RegisterAlgorithm, usually from an init function.
+type Algorithm interface {
+	// Name is the value used for `algorithm` in the config file.
+	Name() string
+
+	// RequiredConfig lists the algorithmConfig keys the algorithm cannot run without.
+	RequiredConfig() []string
+
+	// MetricNames lists the names of the metrics set on every participant, see Metrics.
+	MetricNames() []string
+
+	// Score fetches data for the verified socials and returns participants, best first.
+	Score(s *Service, socials map[string]verifier.Social) ([]Participant, error)
+}
+
+// ExclusionRanker is implemented by algorithms whose public leaderboard is made of the
+// excluded participants. The participants that are not excluded are served as excluded instead.
+type ExclusionRanker interface {
+	RanksExcluded() bool
+}
+
+// ScoreFunc is the signature shared by the sortBy* methods on Service.
+type ScoreFunc func(s *Service, socials map[string]verifier.Social) ([]Participant, error)
+
+type algorithm struct {
+	name     string
+	required []string
+	metrics  []string
+	score    ScoreFunc
+}
+
+func (a *algorithm) Name() string             { return a.name }
+func (a *algorithm) RequiredConfig() []string { return a.required }
+func (a *algorithm) MetricNames() []string    { return a.metrics }
+
+func (a *algorithm) Score(s *Service, socials map[string]verifier.Social) ([]Participant, error) {
+	return a.score(s, socials)
+}
+
+// NewAlgorithm creates an Algorithm from its parts.
+func NewAlgorithm(name string, required []string, metrics []string, score ScoreFunc) Algorithm {
+	return &algorithm{
+		name:     name,
+		required: required,
+		metrics:  metrics,
+		score:    score,
+	}
+}
+
+var (
+	algorithmsMu sync.RWMutex
+	algorithms   = map[string]Algorithm{}
+)
+
+// RegisterAlgorithm makes an algorithm available by name. It panics if the name is empty or already taken.
+func RegisterAlgorithm(a Algorithm) {
+	algorithmsMu.Lock()
+	defer algorithmsMu.Unlock()
+
+	name := a.Name()
+	if name == "" {
+		panic("leaderboard: algorithm registered without a name")
+	}
+	if _, found := algorithms[name]; found {
+		panic(fmt.Sprintf("leaderboard: algorithm registered twice: %s", name))
+	}
+	algorithms[name] = a
+}
+
+// LookupAlgorithm returns the registered algorithm with the given name.
+func LookupAlgorithm(name string) (Algorithm, bool) {
+	algorithmsMu.RLock()
+	defer algorithmsMu.RUnlock()
+	a, found := algorithms[name]
+	return a, found
+}
+
+// AlgorithmNames returns the names of all registered algorithms, sorted.
+func AlgorithmNames() []string {
+	algorithmsMu.RLock()
+	defer algorithmsMu.RUnlock()
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AlgorithmValidator checks config against the algorithm registry.
+// It satisfies config.AlgorithmValidator.
+type AlgorithmValidator struct{}
+
+// ValidateAlgorithm returns an error if the named algorithm is not registered,
+// if any of its required algorithmConfig keys are missing, or if the algorithm
+// implements ConfigValidator and rejects the config.
+func (AlgorithmValidator) ValidateAlgorithm(name string, algorithmConfig map[string]string) error {
+	a, found := LookupAlgorithm(name)
+	if !found {
+		return fmt.Errorf("unknown algorithm: %s (available: %s)", name, strings.Join(AlgorithmNames(), ", "))
+	}
+	missing := []string{}
+	for _, key := range a.RequiredConfig() {
+		if _, found := algorithmConfig[key]; !found {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing algorithmConfig for %s: %s", name, strings.Join(missing, ", "))
+	}
+	if v, ok := a.(ConfigValidator); ok {
+		if err := v.ValidateConfig(algorithmConfig); err != nil {
+			return fmt.Errorf("invalid algorithmConfig for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateSort returns an error if defaultSort is not one of the metric names of the named
+// algorithm, compared case-insensitively. Unknown algorithms are left to ValidateAlgorithm.
+func (AlgorithmValidator) ValidateSort(name string, defaultSort string) error {
+	a, found := LookupAlgorithm(name)
+	if !found {
+		return nil
+	}
+	if _, found := metricName(a.MetricNames(), defaultSort); !found {
+		return fmt.Errorf("invalid defaultSort for %s: %s (available: %s)", name, defaultSort, strings.Join(a.MetricNames(), ", "))
+	}
+	return nil
+}
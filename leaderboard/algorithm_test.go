@@ -0,0 +1,58 @@
+package leaderboard_test
+
+import (
+	"testing"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlgorithmRegistryHasBuiltins(t *testing.T) {
+	names := leaderboard.AlgorithmNames()
+	require.Contains(t, names, "ByPartyPositions")
+	require.Contains(t, names, "BySocialRegistration")
+
+	a, found := leaderboard.LookupAlgorithm("ByPartyAccountGeneralProfitLP")
+	require.True(t, found)
+	require.ElementsMatch(t, []string{"marketID"}, a.RequiredConfig())
+}
+
+func TestValidateAlgorithm(t *testing.T) {
+	v := leaderboard.AlgorithmValidator{}
+
+	require.NoError(t, v.ValidateAlgorithm("ByPartyGovernanceVotes", nil))
+	require.NoError(t, v.ValidateAlgorithm("ByPartyPositions", nil))
+
+	err := v.ValidateAlgorithm("ByNothingAtAll", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown algorithm")
+
+	err = v.ValidateAlgorithm("ByLPCommittedList", map[string]string{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "marketID")
+}
+
+func TestValidateSort(t *testing.T) {
+	v := leaderboard.AlgorithmValidator{}
+
+	require.NoError(t, v.ValidateSort("ByPartyAccountGeneralProfit", "profit"))
+	require.NoError(t, v.ValidateSort("ByPartyPositions", "PnL"))
+
+	err := v.ValidateSort("ByPartyGovernanceVotes", "Vote Count")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "votes")
+}
+
+func TestAlgorithmsNameTheirMetrics(t *testing.T) {
+	for _, name := range leaderboard.AlgorithmNames() {
+		a, _ := leaderboard.LookupAlgorithm(name)
+		require.NotEmpty(t, a.MetricNames(), name)
+	}
+}
+
+func TestRegisterAlgorithmTwicePanics(t *testing.T) {
+	require.Panics(t, func() {
+		leaderboard.RegisterAlgorithm(leaderboard.NewAlgorithm("ByPartyPositions", nil, nil, nil))
+	})
+}
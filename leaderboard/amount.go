@@ -0,0 +1,58 @@
+package leaderboard
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// percentPlaces is the number of decimal places shown for percentages and other ratios.
+const percentPlaces = 10
+
+// parseAmount parses a number sent by Vega, e.g. a balance as an integer in the asset's
+// smallest unit. An empty amount is zero. An invalid amount is an error, so that the update
+// fails and the board is marked stale instead of scoring it as zero.
+func parseAmount(raw string) (decimal.Decimal, error) {
+	if raw == "" {
+		return decimal.Zero, nil
+	}
+	d, err := decimal.NewFromString(raw)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid amount %q: %w", raw, err)
+	}
+	return d, nil
+}
+
+// assetAmount parses an amount in the asset's smallest unit and returns it in whole units.
+func assetAmount(raw string, asset Asset) (decimal.Decimal, error) {
+	d, err := parseAmount(raw)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return d.Shift(-int32(asset.Decimals)), nil
+}
+
+// formatAmount formats an amount in whole units with the asset's number of decimals.
+func formatAmount(d decimal.Decimal, asset Asset) string {
+	return d.StringFixed(int32(asset.Decimals))
+}
+
+// formatPercent formats a ratio already multiplied by 100.
+func formatPercent(d decimal.Decimal) string {
+	return d.StringFixed(percentPlaces)
+}
+
+// sortDescending orders participants by score, highest first.
+func sortDescending(participants []Participant) {
+	sort.Slice(participants, func(i, j int) bool {
+		return participants[i].sortNum.GreaterThan(participants[j].sortNum)
+	})
+}
+
+// sortAscending orders participants by score, lowest first.
+func sortAscending(participants []Participant) {
+	sort.Slice(participants, func(i, j int) bool {
+		return participants[i].sortNum.LessThan(participants[j].sortNum)
+	})
+}
@@ -0,0 +1,79 @@
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vegaprotocol/topgun-service/datanode"
+)
+
+var gqlQueryAssets string = `{
+	assetsConnection {
+	  edges {
+		node {
+		  id
+		  name
+		  symbol
+		  decimals
+		}
+	  }
+	}
+  }`
+
+type AssetsConnection struct {
+	Edges []AssetsEdge `json:"edges"`
+}
+
+type AssetsEdge struct {
+	Asset Asset `json:"node"`
+}
+
+type AssetsResponse struct {
+	AssetsConnection AssetsConnection `json:"assetsConnection"`
+}
+
+func getAssets(
+	ctx context.Context,
+	client *datanode.Client,
+) ([]Asset, error) {
+	var response AssetsResponse
+	if err := client.Run(ctx, gqlQueryAssets, nil, &response); err != nil {
+		return nil, err
+	}
+	assets := make([]Asset, 0, len(response.AssetsConnection.Edges))
+	for _, e := range response.AssetsConnection.Edges {
+		assets = append(assets, e.Asset)
+	}
+	return assets, nil
+}
+
+// asset returns the details of an asset, including its number of decimals. Assets
+// are fetched from Vega the first time one is needed, and again for unknown IDs.
+func (s *Service) asset(id string) (Asset, error) {
+	s.assetsMu.Lock()
+	defer s.assetsMu.Unlock()
+
+	if a, found := s.assets[id]; found {
+		return a, nil
+	}
+	assets, err := getAssets(s.ctx, s.dataNode)
+	if err != nil {
+		return Asset{}, fmt.Errorf("failed to get assets: %w", err)
+	}
+	s.assets = make(map[string]Asset, len(assets))
+	for _, a := range assets {
+		s.assets[a.Id] = a
+	}
+	if a, found := s.assets[id]; found {
+		return a, nil
+	}
+	return Asset{}, fmt.Errorf("unknown asset: %s", id)
+}
+
+// settlementAsset returns the first configured asset, which PnL and other market amounts are paid in.
+func (s *Service) settlementAsset() (Asset, error) {
+	if len(s.cfg.VegaAssets) == 0 {
+		return Asset{}, fmt.Errorf("missing vegaAssets")
+	}
+	return s.asset(s.cfg.VegaAssets[0])
+}
@@ -0,0 +1,270 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/datastore"
+
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Baseline is a leaderboard frozen under a name, e.g. the results at the end of day 1.
+type Baseline struct {
+	Competition  string        `bson:"competition"`
+	Name         string        `bson:"name"`
+	CapturedAt   time.Time     `bson:"captured_at"`
+	Participants []Participant `bson:"participants"`
+}
+
+// Scores returns the score of every participant, by public key. Participants without a
+// score, as in the hand-made /data/*.json files, are read from their first data column.
+// A participant without a numeric score means the baseline is corrupt.
+func (b *Baseline) Scores() (map[string]decimal.Decimal, error) {
+	scores := make(map[string]decimal.Decimal, len(b.Participants))
+	for _, p := range b.Participants {
+		raw := p.Score
+		if raw == "" {
+			if len(p.Data) == 0 {
+				return nil, fmt.Errorf("baseline %s is corrupt: no data for %s", b.Name, p.PublicKey)
+			}
+			raw = p.Data[0]
+		}
+		score, err := decimal.NewFromString(raw)
+		if err != nil {
+			return nil, fmt.Errorf("baseline %s is corrupt: invalid data for %s: %w", b.Name, p.PublicKey, err)
+		}
+		scores[p.PublicKey] = score
+	}
+	return scores, nil
+}
+
+// BaselineStore persists named baselines.
+type BaselineStore interface {
+	// SaveBaseline stores a baseline, replacing any with the same competition and name.
+	SaveBaseline(b Baseline) error
+
+	// LoadBaseline returns a baseline, or nil if there is none with that name.
+	LoadBaseline(competition string, name string) (*Baseline, error)
+}
+
+// FileBaselineStore keeps each baseline as a JSON array of participants in <dir>/<competition>/<name>.json.
+// This is the format of the hand-made /data/*.json files used by older competitions.
+type FileBaselineStore struct {
+	dir string
+}
+
+// NewFileBaselineStore creates a BaselineStore in a directory.
+func NewFileBaselineStore(dir string) *FileBaselineStore {
+	return &FileBaselineStore{dir: dir}
+}
+
+func (f *FileBaselineStore) path(competition string, name string) string {
+	return filepath.Join(f.dir, competition, name+".json")
+}
+
+func (f *FileBaselineStore) SaveBaseline(b Baseline) error {
+	payload, err := json.Marshal(b.Participants)
+	if err != nil {
+		return fmt.Errorf("failed to marshal baseline %s: %w", b.Name, err)
+	}
+	path := f.path(b.Competition, b.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create baseline directory: %w", err)
+	}
+	// Write then rename, so a crash never leaves a half written baseline behind
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, payload, 0o644); err != nil {
+		return fmt.Errorf("failed to write baseline %s: %w", b.Name, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func (f *FileBaselineStore) LoadBaseline(competition string, name string) (*Baseline, error) {
+	path := f.path(competition, name)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open baseline %s: %w", name, err)
+	}
+	payload, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline %s: %w", name, err)
+	}
+	b := Baseline{Competition: competition, Name: name, CapturedAt: info.ModTime().UTC()}
+	if err := json.Unmarshal(payload, &b.Participants); err != nil {
+		return nil, fmt.Errorf("baseline %s is corrupt: %w", name, err)
+	}
+	return &b, nil
+}
+
+// MongoBaselineStore keeps baselines of every competition in a single MongoDB collection.
+type MongoBaselineStore struct {
+	ds             *datastore.Service
+	databaseName   string
+	collectionName string
+}
+
+// NewMongoBaselineStore creates a BaselineStore using a connected datastore.
+func NewMongoBaselineStore(ds *datastore.Service, databaseName string, collectionName string) *MongoBaselineStore {
+	return &MongoBaselineStore{
+		ds:             ds,
+		databaseName:   databaseName,
+		collectionName: collectionName,
+	}
+}
+
+// SaveBaseline inserts the baseline. LoadBaseline returns the latest one with a name,
+// so earlier copies are kept for reference rather than replaced.
+func (m *MongoBaselineStore) SaveBaseline(b Baseline) error {
+	return m.ds.InsertDocument(m.databaseName, m.collectionName, b)
+}
+
+func (m *MongoBaselineStore) LoadBaseline(competition string, name string) (*Baseline, error) {
+	var b Baseline
+	found, err := m.ds.FindOneDocument(
+		m.databaseName,
+		m.collectionName,
+		bson.D{{Key: "competition", Value: competition}, {Key: "name", Value: name}},
+		bson.D{{Key: "captured_at", Value: -1}},
+		&b,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load baseline %s: %w", name, err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &b, nil
+}
+
+// UsesBaselines returns true if a competition captures baselines, or runs an algorithm that reads them.
+func UsesBaselines(comp config.Competition) bool {
+	if len(comp.Baselines) > 0 {
+		return true
+	}
+	a, found := LookupAlgorithm(comp.Algorithm)
+	if !found {
+		return false
+	}
+	for _, key := range a.RequiredConfig() {
+		if key == "baseline" || key == "periods" {
+			return true
+		}
+	}
+	return false
+}
+
+// SetBaselineStore enables baselines. It must be called before Start.
+func (s *Service) SetBaselineStore(store BaselineStore) {
+	s.baselines = store
+}
+
+// baselineScores loads a baseline by name and returns its scores by public key.
+// A missing store, a missing baseline or a corrupt baseline are all errors, an
+// algorithm must never silently score against an empty baseline.
+func (s *Service) baselineScores(name string) (map[string]decimal.Decimal, error) {
+	if s.baselines == nil {
+		return nil, fmt.Errorf("baseline %s: no baseline store configured", name)
+	}
+	b, err := s.baselines.LoadBaseline(s.ID(), name)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, fmt.Errorf("baseline %s not found for competition %s", name, s.ID())
+	}
+	return b.Scores()
+}
+
+// baselineScoresFromConfig loads the baseline named by an algorithmConfig key.
+func (s *Service) baselineScoresFromConfig(key string) (map[string]decimal.Decimal, error) {
+	name, err := s.getAlgorithmConfig(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get algorithm config: %w", err)
+	}
+	scores, err := s.baselineScores(name)
+	if err != nil {
+		log.WithError(err).WithField("competition", s.ID()).Error("Failed to load baseline")
+		return nil, err
+	}
+	return scores, nil
+}
+
+// captureBaselines freezes the leaderboard under every configured baseline name whose time
+// has passed and which has not been stored yet. It runs before each update, so the board
+// frozen is the last one computed before the baseline time.
+func (s *Service) captureBaselines() {
+	if s.baselines == nil || len(s.cfg.Baselines) == 0 {
+		return
+	}
+	names := make([]string, 0, len(s.cfg.Baselines))
+	for name := range s.cfg.Baselines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	for _, name := range names {
+		at := s.cfg.Baselines[name]
+		if now.Before(at) {
+			continue
+		}
+		logger := log.WithFields(log.Fields{"competition": s.ID(), "baseline": name})
+		existing, err := s.baselines.LoadBaseline(s.ID(), name)
+		if err != nil {
+			logger.WithError(err).Error("Failed to check baseline")
+			continue
+		}
+		if existing != nil {
+			continue
+		}
+
+		participants, found := s.participantsAt(at)
+		if !found {
+			logger.Warn("No leaderboard to capture as baseline yet")
+			continue
+		}
+		b := Baseline{
+			Competition:  s.ID(),
+			Name:         name,
+			CapturedAt:   now.UTC(),
+			Participants: participants,
+		}
+		if err := s.baselines.SaveBaseline(b); err != nil {
+			logger.WithError(err).Error("Failed to save baseline")
+			continue
+		}
+		logger.WithField("participants", len(participants)).Info("Captured baseline")
+	}
+}
+
+// participantsAt returns the last known participants at or before a time. Snapshots are used
+// when available, so a baseline missed while the service was down is still captured correctly.
+func (s *Service) participantsAt(at time.Time) ([]Participant, bool) {
+	if s.snapshots != nil {
+		snap, err := s.snapshots.SnapshotBefore(s.ID(), at)
+		if err != nil {
+			log.WithError(err).WithField("competition", s.ID()).Warn("Failed to load snapshot for baseline")
+		} else if snap != nil {
+			return snap.Participants, true
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.board.Status == competitionLoading {
+		return nil, false
+	}
+	return s.board.Participants, true
+}
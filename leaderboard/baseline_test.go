@@ -0,0 +1,136 @@
+package leaderboard_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileBaselineStore(t *testing.T) {
+	dir := t.TempDir()
+	store := leaderboard.NewFileBaselineStore(dir)
+
+	b, err := store.LoadBaseline("default", "day1")
+	require.NoError(t, err)
+	require.Nil(t, b)
+
+	require.NoError(t, store.SaveBaseline(leaderboard.Baseline{
+		Competition:  "default",
+		Name:         "day1",
+		Participants: []leaderboard.Participant{{Position: 1, PublicKey: "p1", Data: []string{"12.5"}}},
+	}))
+	b, err = store.LoadBaseline("default", "day1")
+	require.NoError(t, err)
+	require.NotNil(t, b)
+	scores, err := b.Scores()
+	require.NoError(t, err)
+	require.Len(t, scores, 1)
+	require.Equal(t, "12.5", scores["p1"].String())
+
+	// Hand-made files use the same format as the old /data/*.json files
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "default", "broken.json"), []byte(`{"not": "a list"`), 0o644))
+	_, err = store.LoadBaseline("default", "broken")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "corrupt")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "default", "nodata.json"), []byte(`[{"publicKey": "p1", "data": []}]`), 0o644))
+	b, err = store.LoadBaseline("default", "nodata")
+	require.NoError(t, err)
+	_, err = b.Scores()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "corrupt")
+}
+
+func TestBaselineCapturedFromSnapshot(t *testing.T) {
+	dayEnd := time.Now().Add(-time.Minute)
+	snapshots := &memorySnapshotStore{snapshots: []leaderboard.Snapshot{
+		{
+			Competition: "default", Sequence: 1, Timestamp: dayEnd.Add(-time.Hour), Status: "active",
+			Participants: []leaderboard.Participant{{Position: 1, PublicKey: "p1", Data: []string{"3"}}},
+		},
+		{
+			Competition: "default", Sequence: 2, Timestamp: dayEnd.Add(time.Second), Status: "active",
+			Participants: []leaderboard.Participant{{Position: 1, PublicKey: "p1", Data: []string{"4"}}},
+		},
+	}}
+	baselines := leaderboard.NewFileBaselineStore(t.TempDir())
+
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	cfg.Baselines = map[string]time.Time{
+		"day1": dayEnd,
+		"day2": time.Now().Add(time.Hour),
+	}
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.SetSnapshotStore(snapshots)
+	svc.SetBaselineStore(baselines)
+	svc.Start()
+	svc.Stop()
+
+	b, err := baselines.LoadBaseline("default", "day1")
+	require.NoError(t, err)
+	require.NotNil(t, b)
+	scores, err := b.Scores()
+	require.NoError(t, err)
+	require.Len(t, scores, 1)
+	require.Equal(t, "3", scores["p1"].String())
+
+	b, err = baselines.LoadBaseline("default", "day2")
+	require.NoError(t, err)
+	require.Nil(t, b)
+}
+
+func TestMissingBaselineFailsUpdate(t *testing.T) {
+	cfg := newPipelineTestConfig(t, nil)
+	cfg.Algorithm = "ByPartyPositionsExisting"
+	cfg.AlgorithmConfig = map[string]string{"baseline": "initial_results"}
+	require.Error(t, leaderboard.AlgorithmValidator{}.ValidateAlgorithm("ByPartyPositionsExisting", nil))
+
+	store := &memorySnapshotStore{}
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.SetSnapshotStore(store)
+	svc.SetBaselineStore(leaderboard.NewFileBaselineStore(t.TempDir()))
+	svc.Start()
+	svc.Stop()
+
+	// No snapshot is written for a failed update
+	require.Empty(t, store.snapshots)
+}
+
+func TestBaselineOfLabelledBoardKeepsScores(t *testing.T) {
+	// The first data column of a labelled board is not a number
+	snapshots := &memorySnapshotStore{}
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL", "format": "label:Traded"})
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.SetSnapshotStore(snapshots)
+	svc.Start()
+	svc.Stop()
+	require.Len(t, snapshots.snapshots, 1)
+	require.Equal(t, []string{"Traded"}, snapshots.snapshots[0].Participants[0].Data)
+
+	// A later competition scores against a baseline captured from that board
+	baselines := leaderboard.NewFileBaselineStore(t.TempDir())
+	next := newPipelineTestConfig(t, map[string]string{"baseline": "day1"})
+	next.Algorithm = "ByPartyPositionsWithTransfersPercentage"
+	next.Baselines = map[string]time.Time{"day1": time.Now()}
+	svc = leaderboard.NewLeaderboardService(next)
+	svc.SetSnapshotStore(snapshots)
+	svc.SetBaselineStore(baselines)
+	svc.Start()
+	defer svc.Stop()
+
+	b, err := baselines.LoadBaseline("default", "day1")
+	require.NoError(t, err)
+	require.NotNil(t, b)
+	scores, err := b.Scores()
+	require.NoError(t, err)
+	require.Equal(t, "9000", scores["p2"].String())
+
+	board := currentBoard(t, svc)
+	require.Empty(t, board.Freshness.LastError)
+	require.Len(t, board.Participants, 2)
+}
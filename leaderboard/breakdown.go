@@ -0,0 +1,344 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrParticipantNotFound is returned for a public key that is not on the board.
+var ErrParticipantNotFound = errors.New("participant not found")
+
+// ScoreBreakdown holds the data a participant's score was calculated from in the last
+// update. Amounts are in whole units of their asset. Lists hold every item fetched for the
+// party, Counted tells whether the algorithm used it.
+type ScoreBreakdown struct {
+	// WindowStart and WindowEnd bound the timestamped data counted, empty if unbounded
+	WindowStart string   `json:"windowStart,omitempty"`
+	WindowEnd   string   `json:"windowEnd,omitempty"`
+	Assets      []string `json:"assets"`
+
+	Markets             []MarketBreakdown    `json:"markets,omitempty"`
+	Accounts            []AccountBreakdown   `json:"accounts,omitempty"`
+	Deposits            []AmountBreakdown    `json:"deposits,omitempty"`
+	Withdrawals         []AmountBreakdown    `json:"withdrawals,omitempty"`
+	Transfers           []AmountBreakdown    `json:"transfers,omitempty"`
+	Rewards             []AmountBreakdown    `json:"rewards,omitempty"`
+	Votes               []VoteBreakdown      `json:"votes,omitempty"`
+	LiquidityProvisions []LiquidityBreakdown `json:"liquidityProvisions,omitempty"`
+
+	// Terms are the values summed into the score, negative terms are subtracted
+	Terms []TermBreakdown `json:"terms"`
+	// Periods are the scores of the periods that were aggregated into the score
+	Periods []PeriodBreakdown `json:"periods,omitempty"`
+	// Notes explain how the algorithm used the data, e.g. a default amount
+	Notes []string `json:"notes,omitempty"`
+	Score string   `json:"score"`
+}
+
+// MarketBreakdown is a party's position in one market.
+type MarketBreakdown struct {
+	MarketID      string `json:"marketId"`
+	RealisedPnL   string `json:"realisedPnL"`
+	UnrealisedPnL string `json:"unrealisedPnL"`
+	OpenVolume    string `json:"openVolume"`
+	Counted       bool   `json:"counted"`
+}
+
+// AccountBreakdown is the balance of one of a party's accounts.
+type AccountBreakdown struct {
+	Type    string `json:"type"`
+	Asset   string `json:"asset"`
+	Balance string `json:"balance"`
+	Counted bool   `json:"counted"`
+}
+
+// AmountBreakdown is a single deposit, withdrawal, transfer or reward. Type is the type of
+// a reward.
+type AmountBreakdown struct {
+	ID      string    `json:"id,omitempty"`
+	Asset   string    `json:"asset"`
+	Amount  string    `json:"amount"`
+	Time    time.Time `json:"time"`
+	Status  string    `json:"status,omitempty"`
+	Type    string    `json:"type,omitempty"`
+	Counted bool      `json:"counted"`
+}
+
+// VoteBreakdown is a governance vote.
+type VoteBreakdown struct {
+	Value   string    `json:"value"`
+	Time    time.Time `json:"time"`
+	Counted bool      `json:"counted"`
+}
+
+// LiquidityBreakdown is a liquidity commitment.
+type LiquidityBreakdown struct {
+	ID               string `json:"id,omitempty"`
+	MarketID         string `json:"marketId"`
+	CommitmentAmount string `json:"commitmentAmount"`
+	Fee              string `json:"fee"`
+	Status           string `json:"status,omitempty"`
+	Counted          bool   `json:"counted"`
+}
+
+// PeriodBreakdown is the score of one period of a period aggregate.
+type PeriodBreakdown struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Score string `json:"score"`
+}
+
+// TermBreakdown is one value summed into a score.
+type TermBreakdown struct {
+	Term     string `json:"term"`
+	Value    string `json:"value"`
+	Negative bool   `json:"negative,omitempty"`
+}
+
+// ParticipantDetail is a participant of the current board with its score breakdown, as
+// served by /participants/{publicKey}. Status and Freshness are those of the board.
+type ParticipantDetail struct {
+	Competition string    `json:"competition"`
+	Algorithm   string    `json:"algorithm"`
+	Headers     []string  `json:"headers"`
+	LastUpdate  string    `json:"lastUpdate"`
+	Status      string    `json:"status"`
+	Freshness   Freshness `json:"freshness"`
+	Blacklisted bool      `json:"blacklisted"`
+	Participant
+	// Breakdown is empty after a restart until the next update
+	Breakdown *ScoreBreakdown `json:"breakdown,omitempty"`
+}
+
+// JsonParticipant returns a participant of the current board, blacklisted or not, with the
+// breakdown of its score.
+func (s *Service) JsonParticipant(publicKey string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	board := s.live()
+	detail := ParticipantDetail{
+		Competition: s.ID(),
+		Algorithm:   s.cfg.Algorithm,
+		Headers:     board.Headers,
+		LastUpdate:  board.LastUpdate,
+		Status:      board.Status,
+		Freshness:   board.Freshness,
+	}
+	found := false
+	for _, p := range board.Participants {
+		if strings.EqualFold(p.PublicKey, publicKey) {
+			detail.Participant, found = p, true
+		}
+	}
+	for _, p := range board.blacklisted {
+		if !found && strings.EqualFold(p.PublicKey, publicKey) {
+			detail.Participant, detail.Blacklisted, found = p, true, true
+		}
+	}
+	if !found {
+		return nil, ErrParticipantNotFound
+	}
+	detail.Breakdown = detail.Participant.breakdown
+	return json.Marshal(detail)
+}
+
+// newScoreBreakdown lists a party's data within scope, counted the way the pipeline terms
+// count it. Algorithms that count differently adjust the Counted flags.
+func newScoreBreakdown(party *Party, sc *pipelineScope) *ScoreBreakdown {
+	b := &ScoreBreakdown{Assets: sc.assets, Terms: []TermBreakdown{}}
+	if !sc.from.IsZero() {
+		b.WindowStart = sc.from.UTC().Format(time.RFC3339)
+	}
+	if !sc.to.IsZero() {
+		b.WindowEnd = sc.to.UTC().Format(time.RFC3339)
+	}
+	for _, e := range party.PositionsConnection.Edges {
+		b.Markets = append(b.Markets, MarketBreakdown{
+			MarketID:      e.Position.Market.ID,
+			RealisedPnL:   shownAmount(e.Position.RealisedPNL, sc.settlement),
+			UnrealisedPnL: shownAmount(e.Position.UnrealisedPNL, sc.settlement),
+			OpenVolume:    shownAmount(e.Position.OpenVolume, Asset{}),
+			Counted:       sc.hasMarket(e.Position.Market.ID),
+		})
+	}
+	for _, e := range party.AccountsConnection.Edges {
+		a := e.Account
+		b.Accounts = append(b.Accounts, AccountBreakdown{
+			Type:    a.Type,
+			Asset:   a.Asset.Id,
+			Balance: shownAmount(a.Balance, sc.decimals[a.Asset.Id]),
+			Counted: sc.hasAsset(a.Asset.Id),
+		})
+	}
+	for _, e := range party.DepositsConnection.Edges {
+		d := e.Deposit
+		b.Deposits = append(b.Deposits, AmountBreakdown{
+			ID:      d.Id,
+			Asset:   d.Asset.Id,
+			Amount:  shownAmount(d.Amount, sc.decimals[d.Asset.Id]),
+			Time:    d.CreatedAt,
+			Status:  d.Status,
+			Counted: sc.hasAsset(d.Asset.Id) && d.Status == "STATUS_FINALIZED" && sc.inWindow(d.CreatedAt),
+		})
+	}
+	for _, e := range party.WithdrawalsConnection.Edges {
+		w := e.Withdrawal
+		b.Withdrawals = append(b.Withdrawals, AmountBreakdown{
+			Asset:   w.Asset.Id,
+			Amount:  shownAmount(w.Amount, sc.decimals[w.Asset.Id]),
+			Time:    w.CreatedAt,
+			Status:  w.Status,
+			Counted: sc.hasAsset(w.Asset.Id) && w.Status == "STATUS_FINALIZED" && sc.inWindow(w.CreatedAt),
+		})
+	}
+	for _, e := range party.TransfersConnection.Edges {
+		t := e.Transfer
+		b.Transfers = append(b.Transfers, AmountBreakdown{
+			ID:      t.Id,
+			Asset:   t.Asset.Id,
+			Amount:  shownAmount(t.Amount, sc.decimals[t.Asset.Id]),
+			Time:    t.Timestamp,
+			Counted: sc.hasAsset(t.Asset.Id) && sc.inWindow(t.Timestamp),
+		})
+	}
+	for _, e := range party.RewardsConnection.Edges {
+		r := e.Reward
+		b.Rewards = append(b.Rewards, AmountBreakdown{
+			Asset:   r.Asset.Id,
+			Amount:  shownAmount(r.Amount, sc.decimals[r.Asset.Id]),
+			Time:    r.ReceivedAt,
+			Type:    r.RewardType,
+			Counted: sc.hasAsset(r.Asset.Id) && sc.inWindow(r.ReceivedAt),
+		})
+	}
+	for _, e := range party.VotesConnection.Edges {
+		v := e.Vote
+		b.Votes = append(b.Votes, VoteBreakdown{
+			Value:   v.Value,
+			Time:    v.Datetime,
+			Counted: sc.inWindow(v.Datetime),
+		})
+	}
+	for _, e := range party.LPsConnection.Edges {
+		lp := e.LP
+		b.LiquidityProvisions = append(b.LiquidityProvisions, LiquidityBreakdown{
+			ID:               lp.ID,
+			MarketID:         lp.Market.ID,
+			CommitmentAmount: shownAmount(lp.CommitmentAmount, sc.settlement),
+			Fee:              lp.Fee,
+			Status:           lp.Status,
+			Counted:          sc.hasMarket(lp.Market.ID),
+		})
+	}
+	return b
+}
+
+// competitionBreakdown lists a party's data in the given assets and the configured markets
+// during the competition, for the algorithms that are not configured by a pipeline. The
+// first asset is the settlement asset. Algorithms adjust the Counted flags to match what
+// they count.
+func (s *Service) competitionBreakdown(party *Party, assets ...Asset) *ScoreBreakdown {
+	sc := &pipelineScope{
+		markets:  s.cfg.MarketIDs,
+		from:     s.cfg.StartTime,
+		to:       s.cfg.EndTime,
+		decimals: map[string]Asset{},
+	}
+	for _, asset := range assets {
+		sc.assets = append(sc.assets, asset.Id)
+		sc.decimals[asset.Id] = asset
+	}
+	if len(assets) > 0 {
+		sc.settlement = assets[0]
+	}
+	return newScoreBreakdown(party, sc)
+}
+
+// positionsBreakdown lists the positions a party's PnL was summed from, with the realised and
+// unrealised PnL as terms.
+func (s *Service) positionsBreakdown(party *Party, asset Asset, realised, unrealised decimal.Decimal) *ScoreBreakdown {
+	b := s.competitionBreakdown(party, asset)
+	b.addTerm("realisedPnL", realised, false)
+	b.addTerm("unrealisedPnL", unrealised, false)
+	return b
+}
+
+// shownAmount formats an amount in whole units, or returns it as sent if it is invalid. The
+// amounts counted in a score were already checked when scoring.
+func shownAmount(raw string, asset Asset) string {
+	d, err := assetAmount(raw, asset)
+	if err != nil {
+		return raw
+	}
+	return d.String()
+}
+
+func (b *ScoreBreakdown) addTerm(term string, value decimal.Decimal, negative bool) {
+	b.Terms = append(b.Terms, TermBreakdown{Term: term, Value: value.String(), Negative: negative})
+}
+
+// balanceBreakdown lists the accounts a party's general and margin balance was summed from.
+func (s *Service) balanceBreakdown(party *Party, asset Asset, balance decimal.Decimal) *ScoreBreakdown {
+	b := s.competitionBreakdown(party, asset)
+	countAccounts(b.Accounts, []string{asset.Id}, "ACCOUNT_TYPE_GENERAL", "ACCOUNT_TYPE_MARGIN")
+	b.addTerm("balance", balance, false)
+	return b
+}
+
+// profitBreakdown lists the balance and the deposits a party's profit was calculated from.
+// Every finalized deposit counts, whenever it was made.
+func (s *Service) profitBreakdown(party *Party, asset Asset, balance, deposits, profit decimal.Decimal) *ScoreBreakdown {
+	b := s.balanceBreakdown(party, asset, balance)
+	countAmounts(b.Deposits, func(d AmountBreakdown) bool {
+		return d.Asset == asset.Id && d.Status == "Finalized"
+	})
+	b.addTerm("totalDeposits", deposits, true)
+	b.Notes = append(b.Notes, "the score is the sum of the terms divided by the total deposits")
+	b.Score = profit.String()
+	return b
+}
+
+// rewardsBreakdown lists the rewards of one type a party's total was summed from.
+func (s *Service) rewardsBreakdown(party *Party, asset Asset, rewardType string, total decimal.Decimal) *ScoreBreakdown {
+	b := s.competitionBreakdown(party, asset)
+	countAmounts(b.Rewards, func(r AmountBreakdown) bool {
+		return r.Counted && r.Type == rewardType
+	})
+	b.addTerm("rewards", total, false)
+	b.Score = total.String()
+	return b
+}
+
+// countAmounts marks the items an algorithm counted.
+func countAmounts(items []AmountBreakdown, counted func(AmountBreakdown) bool) {
+	for i := range items {
+		items[i].Counted = counted(items[i])
+	}
+}
+
+// countAccounts marks the accounts of an asset with one of the given types as counted.
+func countAccounts(items []AccountBreakdown, assetIDs []string, types ...string) {
+	for i := range items {
+		items[i].Counted = hasString(assetIDs, items[i].Asset) && hasString(types, items[i].Type)
+	}
+}
+
+// countLiquidity marks the liquidity commitments on a market as counted.
+func countLiquidity(items []LiquidityBreakdown, marketID string) {
+	for i := range items {
+		items[i].Counted = items[i].MarketID == marketID
+	}
+}
+
+// countOnly marks only the item with the given ID as counted, for algorithms that use a
+// single item of a list.
+func countOnly(items []AmountBreakdown, id string) {
+	for i := range items {
+		items[i].Counted = id != "" && items[i].ID == id
+	}
+}
@@ -0,0 +1,123 @@
+package leaderboard_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func participantDetail(t *testing.T, svc *leaderboard.Service, publicKey string) leaderboard.ParticipantDetail {
+	payload, err := svc.JsonParticipant(publicKey)
+	require.NoError(t, err)
+	var detail leaderboard.ParticipantDetail
+	require.NoError(t, json.Unmarshal(payload, &detail))
+	return detail
+}
+
+func TestParticipantBreakdown(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL - transfers"})
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	detail := participantDetail(t, svc, "p1")
+	require.Equal(t, 2, detail.Position)
+	require.Equal(t, "ByPipeline", detail.Algorithm)
+	require.False(t, detail.Blacklisted)
+
+	b := detail.Breakdown
+	require.NotNil(t, b)
+	require.Equal(t, []string{"a1"}, b.Assets)
+	require.Equal(t, "2023-03-17T10:00:00Z", b.WindowStart)
+	require.Equal(t, []leaderboard.TermBreakdown{
+		{Term: "realisedPnL", Value: "8000"},
+		{Term: "transfers", Value: "1000", Negative: true},
+	}, b.Terms)
+	require.Equal(t, "7000", b.Score)
+
+	counted := map[string]bool{}
+	for _, m := range b.Markets {
+		counted[m.MarketID] = m.Counted
+	}
+	require.Equal(t, map[string]bool{"m1": true, "m2": true, "other": false}, counted)
+	require.Len(t, b.Transfers, 2)
+	require.True(t, b.Transfers[0].Counted)
+	require.False(t, b.Transfers[1].Counted, "the transfer before the window is not counted")
+
+	_, err := svc.JsonParticipant("unknown")
+	require.Equal(t, leaderboard.ErrParticipantNotFound, err)
+}
+
+func TestParticipantBreakdownPositionsWithTransfers(t *testing.T) {
+	cfg := newPipelineTestConfig(t, nil)
+	cfg.Algorithm = "ByPartyPositionsWithTransfers"
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	b := participantDetail(t, svc, "p1").Breakdown
+	require.NotNil(t, b)
+	require.Equal(t, []leaderboard.TermBreakdown{
+		{Term: "realisedPnL", Value: "8000"},
+		{Term: "unrealisedPnL", Value: "0"},
+		{Term: "transfer", Value: "1000", Negative: true},
+		{Term: "deposit", Value: "0", Negative: true},
+	}, b.Terms)
+	require.Equal(t, "7000", b.Score)
+	require.Empty(t, b.Notes)
+}
+
+func TestParticipantBreakdownPositionsPubkeys(t *testing.T) {
+	cfg := newPipelineTestConfig(t, nil)
+	cfg.Algorithm = "ByPartyPositionsPubkeys"
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	detail := participantDetail(t, svc, "p2")
+	require.Equal(t, "active", detail.Status)
+	require.False(t, detail.Freshness.Stale)
+	b := detail.Breakdown
+	require.NotNil(t, b)
+	require.Equal(t, []leaderboard.TermBreakdown{
+		{Term: "realisedPnL", Value: "9000"},
+		{Term: "unrealisedPnL", Value: "0"},
+		{Term: "transfer", Value: "0", Negative: true},
+		{Term: "deposit", Value: "0", Negative: true},
+	}, b.Terms)
+	require.Equal(t, "9000", b.Score)
+	require.True(t, b.Transfers[0].Counted)
+}
+
+func TestParticipantBreakdownPeriodAggregate(t *testing.T) {
+	boundary := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	baselines := leaderboard.NewFileBaselineStore(t.TempDir())
+	require.NoError(t, baselines.SaveBaseline(leaderboard.Baseline{
+		Competition:  "default",
+		Name:         "period-1",
+		Participants: []leaderboard.Participant{{PublicKey: "p2", Score: "4000"}},
+	}))
+	cfg := newPipelineTestConfig(t, map[string]string{
+		"metric":    "realisedPnL",
+		"periods":   boundary.Format(time.RFC3339),
+		"aggregate": "sum",
+	})
+	cfg.Algorithm = "ByPeriodAggregate"
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.SetBaselineStore(baselines)
+	svc.Start()
+	defer svc.Stop()
+
+	b := participantDetail(t, svc, "p2").Breakdown
+	require.NotNil(t, b)
+	require.Equal(t, []leaderboard.TermBreakdown{{Term: "realisedPnL", Value: "9000"}}, b.Terms)
+	require.Equal(t, []leaderboard.PeriodBreakdown{
+		{Start: "2023-03-17T10:00:00Z", End: boundary.Format(time.RFC3339), Score: "4000"},
+		{Start: boundary.Format(time.RFC3339), End: cfg.EndTime.UTC().Format(time.RFC3339), Score: "5000"},
+	}, b.Periods)
+	require.Equal(t, "9000", b.Score)
+}
@@ -0,0 +1,97 @@
+package leaderboard
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/verifier"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// exclusionList is the single list of public keys and social accounts filtered from the
+// public leaderboard results of a competition.
+type exclusionList []config.Exclusion
+
+// newExclusionList merges the configured exclusions and the deprecated twitterBlacklist.
+func newExclusionList(cfg config.Config) exclusionList {
+	list := exclusionList{}
+	list = append(list, cfg.Exclusions...)
+	for key, handle := range cfg.TwitterBlacklist {
+		exclusion := config.Exclusion{Provider: verifier.ProviderTwitter, UserID: key, Reason: "twitterBlacklist: " + handle}
+		if i := strings.Index(key, ":"); i >= 0 {
+			exclusion.Provider, exclusion.UserID = key[:i], key[i+1:]
+		}
+		list = append(list, exclusion)
+	}
+	return list
+}
+
+// match returns the first unexpired exclusion matching the public key or its identity.
+func (l exclusionList) match(publicKey string, identity verifier.Identity, now time.Time) (config.Exclusion, bool) {
+	for _, exclusion := range l {
+		if !exclusion.Expires.IsZero() && !now.Before(exclusion.Expires) {
+			continue
+		}
+		if matchesExclusion(exclusion, publicKey, identity) {
+			return exclusion, true
+		}
+	}
+	return config.Exclusion{}, false
+}
+
+func matchesExclusion(exclusion config.Exclusion, publicKey string, identity verifier.Identity) bool {
+	if len(exclusion.PublicKey) > 0 {
+		return strings.EqualFold(exclusion.PublicKey, publicKey)
+	}
+	if len(exclusion.Provider) > 0 && !strings.EqualFold(exclusion.Provider, identity.Provider) {
+		return false
+	}
+	if len(exclusion.UserID) > 0 {
+		return exclusion.UserID == identity.UserID
+	}
+	if len(exclusion.Handle) > 0 && len(identity.Handle) > 0 {
+		matched, _ := path.Match(strings.ToLower(exclusion.Handle), strings.ToLower(identity.Handle))
+		return matched
+	}
+	return false
+}
+
+// currentExclusions returns the configured exclusions followed by those added at runtime.
+func (s *Service) currentExclusions() exclusionList {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make(exclusionList, 0, len(s.exclusions)+len(s.runtimeExclusions))
+	list = append(list, s.exclusions...)
+	return append(list, s.runtimeExclusions...)
+}
+
+// SetRuntimeExclusions replaces the exclusions added at runtime, e.g. through the admin API.
+// They apply from the next update.
+func (s *Service) SetRuntimeExclusions(exclusions []config.Exclusion) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runtimeExclusions = exclusions
+}
+
+// excludeParticipants is the only place exclusions apply: it marks the excluded participants
+// after scoring, so that ranking separates them from the public leaderboard. Participants
+// are matched by public key and, when verified, by social identity.
+func (s *Service) excludeParticipants(exclusions exclusionList, participants []Participant, now time.Time) {
+	for i, p := range participants {
+		exclusion, found := exclusions.match(p.PublicKey, p.Identity, now)
+		if !found {
+			continue
+		}
+		participants[i].isBlacklisted = true
+		participants[i].ExclusionReason = exclusion.Reason
+		log.WithFields(log.Fields{
+			"competition": s.ID(),
+			"publicKey":   p.PublicKey,
+			"identity":    p.Identity.String(),
+			"reason":      exclusion.Reason,
+		}).Debug("Participant excluded")
+	}
+}
@@ -0,0 +1,63 @@
+package leaderboard_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExclusionsSeparateParticipantsBeforeRanking(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	cfg.Exclusions = []config.Exclusion{
+		{Handle: "TW*", Reason: "team member"},
+		{PublicKey: "p1", Reason: "suspected bot", Expires: time.Now().Add(-time.Hour)},
+	}
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	// p2 would rank first, the expired exclusion of p1 no longer applies
+	board := currentBoard(t, svc)
+	require.Equal(t, []string{"p1"}, publicKeys(board))
+	require.Equal(t, []int{1}, positions(board))
+	require.Empty(t, board.Participants[0].ExclusionReason)
+
+	payload, err := svc.JsonLeaderboard("", 0, 0, true, leaderboard.Sort{})
+	require.NoError(t, err)
+	var excluded leaderboard.Leaderboard
+	require.NoError(t, json.Unmarshal(payload, &excluded))
+	require.Equal(t, []string{"p2"}, publicKeys(excluded))
+	require.Equal(t, "team member", excluded.Participants[0].ExclusionReason)
+}
+
+func TestTwitterBlacklistIsAnExclusion(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	cfg.TwitterBlacklist = map[string]string{"1": "one"}
+	board := rankedBoard(t, cfg)
+
+	require.Equal(t, []string{"p2"}, publicKeys(board))
+}
+
+func TestInternalRanksExcludedParticipants(t *testing.T) {
+	cfg := newPipelineTestConfig(t, nil)
+	cfg.Algorithm = "ByPartyPositionsInternal"
+	cfg.Exclusions = []config.Exclusion{{PublicKey: "p2", Reason: "team member"}}
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	board := currentBoard(t, svc)
+	require.Equal(t, []string{"p2"}, publicKeys(board))
+	require.Equal(t, "team member", board.Participants[0].ExclusionReason)
+
+	payload, err := svc.JsonLeaderboard("", 0, 0, true, leaderboard.Sort{})
+	require.NoError(t, err)
+	var others leaderboard.Leaderboard
+	require.NoError(t, json.Unmarshal(payload, &others))
+	require.Equal(t, []string{"p1"}, publicKeys(others))
+}
@@ -0,0 +1,143 @@
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vegaprotocol/topgun-service/datanode"
+	"github.com/vegaprotocol/topgun-service/verifier"
+)
+
+const (
+	// partyBatchSize is the number of parties requested in one query.
+	partyBatchSize = 25
+	// fetchConcurrency is the number of party batches requested at the same time.
+	fetchConcurrency = 4
+)
+
+// socialKeys returns the public keys of the verified socials, sorted.
+func socialKeys(socials map[string]verifier.Social) []string {
+	keys := make([]string, 0, len(socials))
+	for key := range socials {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// partyBatchQuery builds a query for n parties at once, each party aliased by its position.
+func partyBatchQuery(n int, names ...string) string {
+	vars := make([]string, 0, n)
+	parties := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		vars = append(vars, fmt.Sprintf("$p%d: ID!", i))
+		parties = append(parties, fmt.Sprintf("p%d: party(id: $p%d) { ...partyFields }", i, i))
+	}
+	return fmt.Sprintf(`query (%s) {
+	%s
+  }
+  %s`, strings.Join(vars, ", "), strings.Join(parties, "\n\t"), partyFragment(names...))
+}
+
+// fetchParties returns the parties with the given public keys, with all the edges of the
+// named connections. Parties are requested in batches of partyBatchSize, with at most
+// fetchConcurrency batches in flight, so the cost of an update follows the number of
+// registered parties rather than the size of the network. Keys unknown to Vega are left out.
+func (s *Service) fetchParties(ctx context.Context, partyIDs []string, names ...string) ([]PartiesEdge, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := [][]string{}
+	for start := 0; start < len(partyIDs); start += partyBatchSize {
+		end := start + partyBatchSize
+		if end > len(partyIDs) {
+			end = len(partyIDs)
+		}
+		batches = append(batches, partyIDs[start:end])
+	}
+
+	results := make([][]PartiesEdge, len(batches))
+	sem := make(chan struct{}, fetchConcurrency)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i int, batch []string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			edges, err := s.fetchPartyBatch(ctx, batch, names)
+			if err != nil {
+				// The first failure cancels the other batches
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = edges
+		}(i, batch)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	partyEdges := make([]PartiesEdge, 0, len(partyIDs))
+	for _, edges := range results {
+		partyEdges = append(partyEdges, edges...)
+	}
+	return partyEdges, nil
+}
+
+func (s *Service) fetchPartyBatch(ctx context.Context, partyIDs []string, names []string) ([]PartiesEdge, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	vars := make(map[string]interface{}, len(partyIDs))
+	for i, id := range partyIDs {
+		vars[fmt.Sprintf("p%d", i)] = id
+	}
+	parties, err := getPartyBatch(
+		ctx,
+		s.dataNode,
+		partyBatchQuery(len(partyIDs), names...),
+		vars,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	partyEdges := make([]PartiesEdge, 0, len(partyIDs))
+	for i := range partyIDs {
+		party := parties[fmt.Sprintf("p%d", i)]
+		if party == nil {
+			// Registered but not yet seen by Vega
+			continue
+		}
+		if err := s.followConnections(ctx, party, names); err != nil {
+			return nil, err
+		}
+		partyEdges = append(partyEdges, PartiesEdge{Party: *party})
+	}
+	return partyEdges, nil
+}
+
+func getPartyBatch(
+	ctx context.Context,
+	client *datanode.Client,
+	gqlQuery string,
+	vars map[string]interface{},
+) (map[string]*Party, error) {
+	var response map[string]*Party
+	if err := client.Run(ctx, gqlQuery, vars, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,75 @@
+package leaderboard_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchQueriesRegisteredPartiesInBatches(t *testing.T) {
+	// 60 registered parties, every other one known to Vega
+	socials := []map[string]interface{}{}
+	network := []string{}
+	for i := 0; i < 60; i++ {
+		id := fmt.Sprintf("r%02d", i)
+		socials = append(socials, map[string]interface{}{"party_id": id, "twitter_handle": id, "twitter_user_id": i + 1})
+		if i%2 == 0 {
+			network = append(network, fmt.Sprintf(`{"node": {"id": %q, "positionsConnection": {"edges": [
+				{"node": {"market": {"id": "m1"}, "openVolume": "1", "realisedPNL": "%d", "unrealisedPNL": "0"}}
+			]}}}`, id, 100+i))
+		}
+	}
+	parties := fmt.Sprintf(`{"data": {"partiesConnection": {"edges": [%s]}}}`, strings.Join(network, ","))
+
+	var (
+		mu      sync.Mutex
+		batches []int
+		scans   int
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/socials", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode(socials))
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var req testGraphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(req.Query, "assetsConnection"):
+			w.Write([]byte(testAssetsResponse))
+		case strings.Contains(req.Query, "p0: party("):
+			mu.Lock()
+			batches = append(batches, len(req.Variables))
+			mu.Unlock()
+			writeTestPartyBatch(t, w, parties, req.Variables)
+		default:
+			mu.Lock()
+			scans++
+			mu.Unlock()
+			w.Write([]byte(parties))
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL", "precision": "0"})
+	cfg.SocialURL, _ = url.Parse(srv.URL + "/socials")
+	cfg.VegaGraphQLURL, _ = url.Parse(srv.URL + "/graphql")
+	board := rankedBoard(t, cfg)
+
+	require.Len(t, board.Participants, 30)
+	require.Equal(t, "r58", board.Participants[0].PublicKey)
+	require.Equal(t, []string{"158"}, board.Participants[0].Data)
+
+	sort.Ints(batches)
+	require.Equal(t, []int{10, 25, 25}, batches)
+	require.Zero(t, scans, "the network should not be scanned")
+}
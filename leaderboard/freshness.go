@@ -0,0 +1,77 @@
+package leaderboard
+
+import (
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/util"
+)
+
+// staleAfterPolls is the number of poll intervals without a successful update after
+// which a board is reported as stale, even if no update has failed.
+const staleAfterPolls = 3
+
+// Freshness describes how current the data of a board is. Times are unix timestamps in
+// seconds, formatted like LastUpdate.
+type Freshness struct {
+	// LastSuccess is the time of the last update that fetched all of its data
+	LastSuccess string `json:"lastSuccess,omitempty"`
+	// LastAttempt is the time of the last update, successful or not
+	LastAttempt string `json:"lastAttempt,omitempty"`
+	// LastError is the error of the last update, empty if it succeeded
+	LastError string `json:"lastError,omitempty"`
+	// Stale is true if the participants may no longer reflect the data on Vega
+	Stale bool `json:"stale"`
+}
+
+// succeeded records an update that fetched all of its data.
+func (f Freshness) succeeded(at string) Freshness {
+	return Freshness{LastSuccess: at, LastAttempt: at}
+}
+
+// failed records an update that failed. The participants of the last success are kept.
+func (f Freshness) failed(at string, err error) Freshness {
+	f.LastAttempt = at
+	f.LastError = err.Error()
+	return f
+}
+
+// stale returns true if the last update failed, or if the last success is older than
+// staleAfterPolls poll intervals.
+func (f Freshness) stale(now time.Time, poll time.Duration) bool {
+	if f.LastError != "" {
+		return true
+	}
+	if f.LastSuccess == "" || poll <= 0 {
+		return false
+	}
+	lastSuccess, err := util.ParseTimestamp(f.LastSuccess)
+	if err != nil {
+		return true
+	}
+	// Allow for the timestamps only having a resolution of one second
+	return now.Sub(lastSuccess) > staleAfterPolls*poll+time.Second
+}
+
+// live returns the current board with its status and staleness worked out. Updates stop
+// outside the competition, so the status is taken from the clock rather than the last
+// update, and only the board of an active competition can be stale. An active competition
+// whose board is stale is reported as degraded, so the frontend can warn that the results
+// may be out of date. The caller holds s.mu.
+func (s *Service) live() Leaderboard {
+	board := s.board
+	now := time.Now()
+	status := s.statusAt(now)
+	if status != competitionActive {
+		board.Status = status
+		board.Freshness.Stale = false
+		return board
+	}
+	if board.Status != competitionLoading {
+		board.Status = status
+	}
+	board.Freshness.Stale = board.Freshness.stale(now, s.cfg.VegaPoll)
+	if board.Freshness.Stale && board.Status == competitionActive {
+		board.Status = competitionDegraded
+	}
+	return board
+}
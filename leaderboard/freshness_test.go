@@ -0,0 +1,144 @@
+package leaderboard_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func currentBoard(t *testing.T, svc *leaderboard.Service) leaderboard.Leaderboard {
+	payload, err := svc.JsonLeaderboard("", 0, 0, false, leaderboard.Sort{})
+	require.NoError(t, err)
+	var board leaderboard.Leaderboard
+	require.NoError(t, json.Unmarshal(payload, &board))
+	return board
+}
+
+func TestFailedUpdateKeepsLastBoardAndReportsDegraded(t *testing.T) {
+	healthy := newTestAPI(t)
+	var failing int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 && r.URL.Path == "/graphql" {
+			w.Write([]byte(`{"errors": [{"message": "data node is behind"}]}`))
+			return
+		}
+		healthy.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	cfg.SocialURL, _ = url.Parse(srv.URL + "/socials")
+	cfg.VegaGraphQLURL, _ = url.Parse(srv.URL + "/graphql")
+	cfg.VegaPoll = 10 * time.Millisecond
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	board := currentBoard(t, svc)
+	require.Equal(t, "active", board.Status)
+	require.False(t, board.Freshness.Stale)
+	require.Empty(t, board.Freshness.LastError)
+	require.Equal(t, board.LastUpdate, board.Freshness.LastSuccess)
+	lastUpdate := board.LastUpdate
+
+	atomic.StoreInt32(&failing, 1)
+	require.Eventually(t, func() bool {
+		return currentBoard(t, svc).Status == "degraded"
+	}, time.Second, 5*time.Millisecond)
+
+	// The participants and LastUpdate of the last successful update are kept
+	board = currentBoard(t, svc)
+	require.True(t, board.Freshness.Stale)
+	require.True(t, strings.Contains(board.Freshness.LastError, "data node is behind"), board.Freshness.LastError)
+	require.Equal(t, lastUpdate, board.LastUpdate)
+	require.Equal(t, lastUpdate, board.Freshness.LastSuccess)
+	require.Equal(t, []string{"p2", "p1"}, publicKeys(board))
+	require.Equal(t, "degraded", svc.Summary().Status)
+
+	atomic.StoreInt32(&failing, 0)
+	require.Eventually(t, func() bool {
+		return currentBoard(t, svc).Status == "active"
+	}, time.Second, 5*time.Millisecond)
+	require.Empty(t, currentBoard(t, svc).Freshness.LastError)
+}
+
+func TestInvalidAmountFailsUpdate(t *testing.T) {
+	healthy := newTestAPI(t)
+	invalid := strings.Replace(testPartiesResponse, `"realisedPNL": "9000"`, `"realisedPNL": "9k"`, 1)
+	var failing int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 && r.URL.Path == "/graphql" {
+			var req testGraphQLRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case strings.Contains(req.Query, "assetsConnection"):
+				w.Write([]byte(testAssetsResponse))
+			case strings.Contains(req.Query, "p0: party("):
+				writeTestPartyBatch(t, w, invalid, req.Variables)
+			default:
+				w.Write([]byte(invalid))
+			}
+			return
+		}
+		healthy.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	cfg.SocialURL, _ = url.Parse(srv.URL + "/socials")
+	cfg.VegaGraphQLURL, _ = url.Parse(srv.URL + "/graphql")
+	cfg.VegaPoll = 10 * time.Millisecond
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	board := currentBoard(t, svc)
+	require.False(t, board.Freshness.Stale)
+	lastUpdate := board.LastUpdate
+
+	// An amount that does not parse fails the update rather than scoring as zero
+	atomic.StoreInt32(&failing, 1)
+	require.Eventually(t, func() bool {
+		return currentBoard(t, svc).Status == "degraded"
+	}, time.Second, 5*time.Millisecond)
+
+	board = currentBoard(t, svc)
+	require.True(t, board.Freshness.Stale)
+	require.True(t, strings.Contains(board.Freshness.LastError, `invalid amount "9k"`), board.Freshness.LastError)
+	require.Equal(t, lastUpdate, board.LastUpdate)
+	require.Equal(t, []string{"p2", "p1"}, publicKeys(board))
+}
+
+func TestEndedBoardIsNotStale(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	cfg.EndTime = time.Now().Add(100 * time.Millisecond)
+	cfg.VegaPoll = 10 * time.Millisecond
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	board := currentBoard(t, svc)
+	require.Equal(t, "active", board.Status)
+
+	// Updates stop at the end time, which must not make the board stale
+	require.Eventually(t, func() bool {
+		return currentBoard(t, svc).Status == "ended"
+	}, time.Second, 5*time.Millisecond)
+	time.Sleep(5 * cfg.VegaPoll)
+
+	board = currentBoard(t, svc)
+	require.Equal(t, "ended", board.Status)
+	require.False(t, board.Freshness.Stale)
+	require.Equal(t, []string{"p2", "p1"}, publicKeys(board))
+	require.Equal(t, "ended", svc.Summary().Status)
+}
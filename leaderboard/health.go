@@ -0,0 +1,92 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/util"
+)
+
+// dataNodePingTimeout bounds the data node check of the readiness checks.
+const dataNodePingTimeout = 2 * time.Second
+
+// HealthCheck is the result of one readiness check, as reported by /readyz.
+type HealthCheck struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Message string `json:"message,omitempty"`
+}
+
+// ReadinessChecks checks that a data node answers a query within dataNodePingTimeout, and
+// that every competition keeps syncing its verified socials and, while active, updating its
+// board. Syncs and updates fail their check after staleAfterPolls poll intervals without a
+// success. Paused competitions are not checked.
+func (h *Host) ReadinessChecks(ctx context.Context, now time.Time) []HealthCheck {
+	checks := []HealthCheck{h.checkDataNode(ctx)}
+	for _, svc := range h.services {
+		checks = append(checks, svc.readinessChecks(now)...)
+	}
+	return checks
+}
+
+func (h *Host) checkDataNode(ctx context.Context) HealthCheck {
+	check := HealthCheck{Name: "dataNode", Healthy: true}
+	if !h.dataNode.Available() {
+		check.Healthy = false
+		check.Message = "the circuit breakers of all data nodes are open"
+		return check
+	}
+	ctx, cancel := context.WithTimeout(ctx, dataNodePingTimeout)
+	defer cancel()
+	if err := h.dataNode.Ping(ctx); err != nil {
+		check.Healthy = false
+		check.Message = err.Error()
+	}
+	return check
+}
+
+func (s *Service) readinessChecks(now time.Time) []HealthCheck {
+	if s.Paused() {
+		return nil
+	}
+	s.mu.RLock()
+	maxAge := staleAfterPolls * s.cfg.VegaPoll
+	freshness := s.board.Freshness
+	s.mu.RUnlock()
+
+	lastSync, syncErr := s.verifier.LastSync()
+	checks := []HealthCheck{checkAge("verifier:"+s.ID(), "sync", lastSync, syncErr, now, maxAge)}
+
+	if s.Status() == competitionActive {
+		var lastSuccess time.Time
+		if freshness.LastSuccess != "" {
+			lastSuccess, _ = util.ParseTimestamp(freshness.LastSuccess)
+		}
+		var updateErr error
+		if freshness.LastError != "" {
+			updateErr = errors.New(freshness.LastError)
+		}
+		checks = append(checks, checkAge("updates:"+s.ID(), "update", lastSuccess, updateErr, now, maxAge))
+	}
+	return checks
+}
+
+// checkAge fails if the last success is missing or older than maxAge. A failure since the
+// last success is reported, but only fails the check once maxAge has passed.
+func checkAge(name string, action string, lastSuccess time.Time, lastErr error, now time.Time, maxAge time.Duration) HealthCheck {
+	check := HealthCheck{Name: name, Healthy: true}
+	if lastSuccess.IsZero() {
+		check.Healthy = false
+		check.Message = fmt.Sprintf("no successful %s yet", action)
+	} else {
+		age := now.Sub(lastSuccess).Truncate(time.Second)
+		check.Healthy = age <= maxAge
+		check.Message = fmt.Sprintf("last successful %s %s ago, limit %s", action, age, maxAge)
+	}
+	if lastErr != nil {
+		check.Message += fmt.Sprintf(", last %s failed: %v", action, lastErr)
+	}
+	return check
+}
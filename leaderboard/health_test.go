@@ -0,0 +1,69 @@
+package leaderboard_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadinessChecks(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	host := leaderboard.NewHost(cfg)
+
+	// Nothing has been synced or updated before the host starts
+	checks := host.ReadinessChecks(context.Background(), time.Now())
+	require.Equal(t, []string{"dataNode", "verifier:default", "updates:default"}, checkNames(checks))
+	require.Equal(t, []bool{true, false, false}, checkResults(checks))
+	require.Equal(t, "no successful sync yet", checks[1].Message)
+
+	host.Start()
+	defer host.Stop()
+	require.Equal(t, []bool{true, true, true}, checkResults(host.ReadinessChecks(context.Background(), time.Now())))
+
+	// Three poll intervals without a success fail the checks
+	later := host.ReadinessChecks(context.Background(), time.Now().Add(3*cfg.VegaPoll+time.Minute))
+	require.Equal(t, []bool{true, false, false}, checkResults(later))
+
+	// Paused competitions are not expected to update
+	host.Default().Pause()
+	require.Equal(t, []string{"dataNode"}, checkNames(host.ReadinessChecks(context.Background(), time.Now().Add(24*time.Hour))))
+}
+
+func checkNames(checks []leaderboard.HealthCheck) []string {
+	names := []string{}
+	for _, check := range checks {
+		names = append(names, check.Name)
+	}
+	return names
+}
+
+func checkResults(checks []leaderboard.HealthCheck) []bool {
+	results := []bool{}
+	for _, check := range checks {
+		results = append(results, check.Healthy)
+	}
+	return results
+}
+
+func TestReadinessChecksProbeDataNode(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+	cfg.VegaGraphQLURL, _ = url.Parse(srv.URL + "/graphql")
+	host := leaderboard.NewHost(cfg)
+
+	check := host.ReadinessChecks(context.Background(), time.Now())[0]
+	require.Equal(t, "dataNode", check.Name)
+	require.False(t, check.Healthy)
+	require.True(t, strings.Contains(check.Message, "status 502"), check.Message)
+}
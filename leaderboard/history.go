@@ -0,0 +1,133 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrHistoryUnavailable is returned by history queries when snapshots are not enabled.
+	ErrHistoryUnavailable = errors.New("leaderboard history is not available, snapshots are disabled")
+
+	// ErrNoSnapshot is returned when no snapshot of the competition has been taken yet.
+	ErrNoSnapshot = errors.New("no leaderboard snapshot found")
+)
+
+// HistoryEntry is a participant's position and score in one snapshot.
+type HistoryEntry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	LastUpdate string    `json:"lastUpdate"`
+	Status     string    `json:"status"`
+	Position   int       `json:"position"`
+	Data       []string  `json:"data"`
+	Metrics    Metrics   `json:"metrics,omitempty"`
+}
+
+// ParticipantHistory is a participant's position and score over time, oldest first.
+type ParticipantHistory struct {
+	PublicKey string         `json:"publicKey"`
+	Headers   []string       `json:"headers"`
+	History   []HistoryEntry `json:"history"`
+}
+
+// JsonLeaderboardAt returns the snapshot closest to a time, in the same shape as JsonLeaderboard.
+func (s *Service) JsonLeaderboardAt(at time.Time, q string, skip int64, size int64, blacklisted bool, by Sort) ([]byte, error) {
+	board, err := s.boardAt(at)
+	if err != nil {
+		return nil, err
+	}
+	view, err := s.view(board, q, skip, size, blacklisted, by)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(view)
+}
+
+// CsvLeaderboardAt returns the snapshot closest to a time, in the same shape as CsvLeaderboard.
+func (s *Service) CsvLeaderboardAt(at time.Time, q string, skip int64, size int64, blacklisted bool, by Sort) ([]byte, error) {
+	board, err := s.boardAt(at)
+	if err != nil {
+		return nil, err
+	}
+	view, err := s.view(board, q, skip, size, blacklisted, by)
+	if err != nil {
+		return nil, err
+	}
+	return s.WriteParticipantsToCsvBytes(view.Participants)
+}
+
+// JsonParticipantHistory returns the position and score of a public key in every snapshot.
+func (s *Service) JsonParticipantHistory(publicKey string) ([]byte, error) {
+	if s.snapshots == nil {
+		return nil, ErrHistoryUnavailable
+	}
+	snaps, err := s.snapshots.ParticipantHistory(s.ID(), publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	headers := s.cfg.Headers
+	s.mu.RUnlock()
+	history := ParticipantHistory{
+		PublicKey: publicKey,
+		Headers:   headers,
+		History:   []HistoryEntry{},
+	}
+	for _, snap := range snaps {
+		for _, p := range snap.Participants {
+			if p.PublicKey != publicKey {
+				continue
+			}
+			history.History = append(history.History, HistoryEntry{
+				Timestamp:  snap.Timestamp,
+				LastUpdate: snap.LastUpdate,
+				Status:     snap.Status,
+				Position:   p.Position,
+				Data:       p.Data,
+				Metrics:    p.Metrics,
+			})
+		}
+	}
+	return json.Marshal(history)
+}
+
+// boardAt builds a board from the snapshot taken closest to a time.
+func (s *Service) boardAt(at time.Time) (Leaderboard, error) {
+	if s.snapshots == nil {
+		return Leaderboard{}, ErrHistoryUnavailable
+	}
+	before, err := s.snapshots.SnapshotBefore(s.ID(), at)
+	if err != nil {
+		return Leaderboard{}, err
+	}
+	after, err := s.snapshots.SnapshotAfter(s.ID(), at)
+	if err != nil {
+		return Leaderboard{}, err
+	}
+
+	snap := before
+	if snap == nil || (after != nil && after.Timestamp.Sub(at) < at.Sub(before.Timestamp)) {
+		snap = after
+	}
+	if snap == nil {
+		return Leaderboard{}, ErrNoSnapshot
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Leaderboard{
+		Version:        1,
+		Assets:         s.cfg.VegaAssets,
+		DefaultDisplay: s.cfg.DefaultDisplay,
+		DefaultSort:    s.cfg.DefaultSort,
+		Description:    s.cfg.Description,
+		Headers:        s.cfg.Headers,
+		LastUpdate:     snap.LastUpdate,
+		Status:         snap.Status,
+		Freshness:      Freshness{LastSuccess: snap.LastUpdate, LastAttempt: snap.LastUpdate},
+		Participants:   snap.Participants,
+		blacklisted:    snap.Blacklisted,
+	}, nil
+}
@@ -0,0 +1,92 @@
+package leaderboard_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newHistoryTestService(t *testing.T) *leaderboard.Service {
+	base := time.Date(2023, 3, 17, 12, 0, 0, 0, time.UTC)
+	store := &memorySnapshotStore{snapshots: []leaderboard.Snapshot{
+		{
+			Competition: "default", Sequence: 1, Timestamp: base, LastUpdate: "1", Status: "active",
+			Participants: []leaderboard.Participant{
+				{Position: 1, PublicKey: "p1", Data: []string{"10"}},
+				{Position: 2, PublicKey: "p2", Data: []string{"5"}},
+			},
+		},
+		{
+			Competition: "default", Sequence: 2, Timestamp: base.Add(time.Hour), LastUpdate: "2", Status: "active",
+			Participants: []leaderboard.Participant{
+				{Position: 1, PublicKey: "p2", Data: []string{"20"}},
+				{Position: 2, PublicKey: "p1", Data: []string{"12"}},
+			},
+		},
+	}}
+
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	cfg.Headers = []string{"PnL"}
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.SetSnapshotStore(store)
+	return svc
+}
+
+func TestLeaderboardAtReturnsClosestSnapshot(t *testing.T) {
+	svc := newHistoryTestService(t)
+	base := time.Date(2023, 3, 17, 12, 0, 0, 0, time.UTC)
+
+	cases := map[time.Time]string{
+		base.Add(-time.Hour):       "1",
+		base.Add(20 * time.Minute): "1",
+		base.Add(40 * time.Minute): "2",
+		base.Add(24 * time.Hour):   "2",
+	}
+	for at, lastUpdate := range cases {
+		payload, err := svc.JsonLeaderboardAt(at, "", 0, 0, false, leaderboard.Sort{})
+		require.NoError(t, err)
+		var board leaderboard.Leaderboard
+		require.NoError(t, json.Unmarshal(payload, &board))
+		require.Equal(t, lastUpdate, board.LastUpdate, at)
+		require.Equal(t, []string{"PnL"}, board.Headers)
+	}
+
+	payload, err := svc.JsonLeaderboardAt(base, "p2", 0, 0, false, leaderboard.Sort{})
+	require.NoError(t, err)
+	var board leaderboard.Leaderboard
+	require.NoError(t, json.Unmarshal(payload, &board))
+	require.Len(t, board.Participants, 1)
+	require.Equal(t, 2, board.Participants[0].Position)
+}
+
+func TestParticipantHistory(t *testing.T) {
+	svc := newHistoryTestService(t)
+
+	payload, err := svc.JsonParticipantHistory("p1")
+	require.NoError(t, err)
+	var history leaderboard.ParticipantHistory
+	require.NoError(t, json.Unmarshal(payload, &history))
+	require.Equal(t, "p1", history.PublicKey)
+	require.Len(t, history.History, 2)
+	require.Equal(t, 1, history.History[0].Position)
+	require.Equal(t, []string{"10"}, history.History[0].Data)
+	require.Equal(t, 2, history.History[1].Position)
+	require.Equal(t, []string{"12"}, history.History[1].Data)
+}
+
+func TestHistoryWithoutSnapshots(t *testing.T) {
+	svc := leaderboard.NewLeaderboardService(newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"}))
+
+	_, err := svc.JsonLeaderboardAt(time.Now(), "", 0, 0, false, leaderboard.Sort{})
+	require.Equal(t, leaderboard.ErrHistoryUnavailable, err)
+	_, err = svc.JsonParticipantHistory("p1")
+	require.Equal(t, leaderboard.ErrHistoryUnavailable, err)
+
+	svc.SetSnapshotStore(&memorySnapshotStore{})
+	_, err = svc.JsonLeaderboardAt(time.Now(), "", 0, 0, false, leaderboard.Sort{})
+	require.Equal(t, leaderboard.ErrNoSnapshot, err)
+}
@@ -0,0 +1,111 @@
+package leaderboard
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/datanode"
+	"github.com/vegaprotocol/topgun-service/verifier"
+)
+
+// CompetitionSummary is a short description of a hosted competition, as listed by the /competitions index.
+type CompetitionSummary struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	Algorithm   string    `json:"algorithm"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+	Status      string    `json:"status"`
+	// Paused is true while updates are paused through the admin API
+	Paused bool `json:"paused,omitempty"`
+}
+
+// Host runs one leaderboard Service per configured competition.
+type Host struct {
+	services []*Service
+	byID     map[string]*Service
+	dataNode *datanode.Client
+
+	// mu guards cfg, the config last loaded or reloaded
+	mu  sync.Mutex
+	cfg config.Config
+}
+
+// NewHost creates a Service for every competition in the config, in config order. The
+// competitions share one data node client, so they also share its circuit breakers.
+func NewHost(cfg config.Config) *Host {
+	h := &Host{byID: map[string]*Service{}, dataNode: NewDataNodeClient(cfg), cfg: cfg}
+	for _, comp := range cfg.CompetitionList() {
+		svc := newLeaderboardService(cfg.ForCompetition(comp), h.dataNode)
+		h.services = append(h.services, svc)
+		h.byID[comp.ID] = svc
+	}
+	return h
+}
+
+// SetSnapshotStore enables snapshots for every competition. It must be called before Start.
+func (h *Host) SetSnapshotStore(store SnapshotStore) {
+	for _, svc := range h.services {
+		svc.SetSnapshotStore(store)
+	}
+}
+
+// SetBaselineStore enables baselines for every competition. It must be called before Start.
+func (h *Host) SetBaselineStore(store BaselineStore) {
+	for _, svc := range h.services {
+		svc.SetBaselineStore(store)
+	}
+}
+
+// SetVerifier replaces the source of verified socials for every competition. It must be called before Start.
+func (h *Host) SetVerifier(v verifier.Verifier) {
+	for _, svc := range h.services {
+		svc.SetVerifier(v)
+	}
+}
+
+// Start starts every competition. Initial updates run concurrently, and Start returns once all have finished.
+func (h *Host) Start() {
+	var wg sync.WaitGroup
+	for _, svc := range h.services {
+		wg.Add(1)
+		go func(svc *Service) {
+			defer wg.Done()
+			svc.Start()
+		}(svc)
+	}
+	wg.Wait()
+}
+
+// Stop stops every competition.
+func (h *Host) Stop() {
+	for _, svc := range h.services {
+		svc.Stop()
+	}
+}
+
+// Get returns the Service for a competition ID.
+func (h *Host) Get(id string) (*Service, bool) {
+	svc, found := h.byID[id]
+	return svc, found
+}
+
+// Default returns the first configured competition, which is served on /leaderboard.
+func (h *Host) Default() *Service {
+	return h.services[0]
+}
+
+// Services returns every hosted Service, in config order.
+func (h *Host) Services() []*Service {
+	return h.services
+}
+
+// Summaries lists every hosted competition with its current status.
+func (h *Host) Summaries() []CompetitionSummary {
+	summaries := make([]CompetitionSummary, 0, len(h.services))
+	for _, svc := range h.services {
+		summaries = append(summaries, svc.Summary())
+	}
+	return summaries
+}
@@ -0,0 +1,63 @@
+package leaderboard_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostRunsEachCompetition(t *testing.T) {
+	cfg := newPipelineTestConfig(t, nil)
+	cfg.Competitions = []config.Competition{
+		{
+			ID:              "pnl",
+			Algorithm:       "ByPipeline",
+			AlgorithmConfig: map[string]string{"metric": "realisedPnL"},
+			Description:     "PnL",
+			StartTime:       cfg.StartTime,
+			EndTime:         cfg.EndTime,
+			VegaAssets:      cfg.VegaAssets,
+			MarketIDs:       cfg.MarketIDs,
+		},
+		{
+			ID:          "later",
+			Algorithm:   "BySocialRegistration",
+			Description: "Registration",
+			StartTime:   time.Now().Add(24 * time.Hour),
+			EndTime:     time.Now().Add(48 * time.Hour),
+			VegaAssets:  cfg.VegaAssets,
+		},
+	}
+
+	host := leaderboard.NewHost(cfg)
+	host.Start()
+	defer host.Stop()
+
+	require.Equal(t, "pnl", host.Default().ID())
+	_, found := host.Get("missing")
+	require.False(t, found)
+
+	later, found := host.Get("later")
+	require.True(t, found)
+	require.Equal(t, "notStarted", later.Status())
+
+	summaries := host.Summaries()
+	require.Len(t, summaries, 2)
+	require.Equal(t, "pnl", summaries[0].ID)
+	require.Equal(t, "active", summaries[0].Status)
+	require.Equal(t, "ByPipeline", summaries[0].Algorithm)
+	require.Equal(t, "later", summaries[1].ID)
+	require.Equal(t, "notStarted", summaries[1].Status)
+}
+
+func TestHostDefaultsToTopLevelCompetition(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+
+	host := leaderboard.NewHost(cfg)
+	require.Len(t, host.Services(), 1)
+	require.Equal(t, config.DefaultCompetitionID, host.Default().ID())
+}
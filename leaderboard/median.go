@@ -2,20 +2,24 @@ package leaderboard
 
 import (
 	"sort"
+
+	"github.com/shopspring/decimal"
 )
 
-func median(data []float64) float64 {
-	dataCopy := make([]float64, len(data))
+func median(data []decimal.Decimal) decimal.Decimal {
+	dataCopy := make([]decimal.Decimal, len(data))
 	copy(dataCopy, data)
 
-	sort.Float64s(dataCopy)
+	sort.Slice(dataCopy, func(i, j int) bool {
+		return dataCopy[i].LessThan(dataCopy[j])
+	})
 
-	var median float64
+	var median decimal.Decimal
 	l := len(dataCopy)
 	if l == 0 {
-		return 0
+		return decimal.Zero
 	} else if l%2 == 0 {
-		median = (dataCopy[l/2-1] + dataCopy[l/2]) / 2
+		median = dataCopy[l/2-1].Add(dataCopy[l/2]).Div(decimal.NewFromInt(2))
 	} else {
 		median = dataCopy[l/2]
 	}
@@ -0,0 +1,174 @@
+package leaderboard
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// MetricType tells how to read the value of a Metric.
+type MetricType string
+
+const (
+	MetricDecimal    MetricType = "decimal"
+	MetricInteger    MetricType = "integer"
+	MetricPercentage MetricType = "percentage"
+	MetricBoolean    MetricType = "boolean"
+	MetricText       MetricType = "text"
+)
+
+// Metric is a named, typed value a participant was scored on. Values are strings so that
+// amounts keep their precision: decimals and integers in base 10, percentages already
+// multiplied by 100, and booleans "true" or "false".
+type Metric struct {
+	Name  string     `json:"-" bson:"name"`
+	Type  MetricType `json:"type" bson:"type"`
+	Value string     `json:"value" bson:"value"`
+
+	// Unit is the unit of the value, e.g. "%" or "votes", and Asset the symbol of the asset
+	// an amount is in
+	Unit  string `json:"unit,omitempty" bson:"unit,omitempty"`
+	Asset string `json:"asset,omitempty" bson:"asset,omitempty"`
+}
+
+// Metrics are the metrics of a participant, in the order the algorithm returned them. They
+// are encoded in JSON as an object keyed by name, in the same order.
+type Metrics []Metric
+
+func (m Metrics) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, metric := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(metric.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(metric)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (m *Metrics) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		*m = nil
+		return nil
+	}
+	if token != json.Delim('{') {
+		return fmt.Errorf("metrics: expected an object, got %v", token)
+	}
+	metrics := Metrics{}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var metric Metric
+		if err := dec.Decode(&metric); err != nil {
+			return err
+		}
+		metric.Name = token.(string)
+		metrics = append(metrics, metric)
+	}
+	*m = metrics
+	return nil
+}
+
+// Names returns the names of the metrics, in order.
+func (m Metrics) Names() []string {
+	names := make([]string, len(m))
+	for i, metric := range m {
+		names[i] = metric.Name
+	}
+	return names
+}
+
+// Get returns the metric with the given name.
+func (m Metrics) Get(name string) (Metric, bool) {
+	for _, metric := range m {
+		if metric.Name == name {
+			return metric, true
+		}
+	}
+	return Metric{}, false
+}
+
+// amountMetric is an amount in whole units of an asset.
+func amountMetric(name string, amount decimal.Decimal, asset Asset) Metric {
+	return Metric{Name: name, Type: MetricDecimal, Value: formatAmount(amount, asset), Asset: asset.Symbol}
+}
+
+// decimalMetric is a number without an asset, with the given number of decimal places.
+func decimalMetric(name string, value decimal.Decimal, places int32) Metric {
+	return Metric{Name: name, Type: MetricDecimal, Value: value.StringFixed(places)}
+}
+
+// percentMetric is a ratio already multiplied by 100.
+func percentMetric(name string, percent decimal.Decimal, places int32) Metric {
+	return Metric{Name: name, Type: MetricPercentage, Value: percent.StringFixed(places), Unit: "%"}
+}
+
+// countMetric is a number of things, e.g. votes.
+func countMetric(name string, count int64, unit string) Metric {
+	return Metric{Name: name, Type: MetricInteger, Value: fmt.Sprintf("%d", count), Unit: unit}
+}
+
+// flagMetric is a condition the participant met, for algorithms that list qualifying parties.
+func flagMetric(name string) Metric {
+	return Metric{Name: name, Type: MetricBoolean, Value: "true"}
+}
+
+// appendMetricColumns adds a column for every metric name, in the order they first appear,
+// to the CSV export of participants. Participants without a metric leave its column empty.
+func appendMetricColumns(data []byte, participants []Participant) ([]byte, error) {
+	names := []string{}
+	for _, p := range participants {
+		for _, name := range p.Metrics.Names() {
+			if !hasString(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return data, nil
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) != len(participants)+1 {
+		return nil, fmt.Errorf("csv has %d rows for %d participants", len(records)-1, len(participants))
+	}
+	records[0] = append(records[0], names...)
+	for i, p := range participants {
+		for _, name := range names {
+			metric, _ := p.Metrics.Get(name)
+			records[i+1] = append(records[i+1], metric.Value)
+		}
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,50 @@
+package leaderboard_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsJSONKeepsOrder(t *testing.T) {
+	metrics := leaderboard.Metrics{
+		{Name: "profit", Type: leaderboard.MetricDecimal, Value: "0.5"},
+		{Name: "balance", Type: leaderboard.MetricDecimal, Value: "150.00", Asset: "A1"},
+		{Name: "voted", Type: leaderboard.MetricBoolean, Value: "true"},
+	}
+	payload, err := json.Marshal(metrics)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"profit": {"type": "decimal", "value": "0.5"},
+		"balance": {"type": "decimal", "value": "150.00", "asset": "A1"},
+		"voted": {"type": "boolean", "value": "true"}
+	}`, string(payload))
+	require.True(t, strings.Index(string(payload), "profit") < strings.Index(string(payload), "balance"))
+
+	var decoded leaderboard.Metrics
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	require.Equal(t, metrics, decoded)
+}
+
+func TestPipelineMetrics(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL", "format": "percent", "precision": "1"})
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	board := currentBoard(t, svc)
+	require.Equal(t, []string{"900000.0%"}, board.Participants[0].Data)
+	require.Equal(t, leaderboard.Metrics{
+		{Name: "score", Type: leaderboard.MetricPercentage, Value: "900000.0", Unit: "%"},
+	}, board.Participants[0].Metrics)
+
+	csv, err := svc.CsvLeaderboard("", 0, 0, false, leaderboard.Sort{})
+	require.NoError(t, err)
+	lines := strings.Split(string(csv), "\n")
+	require.True(t, strings.HasSuffix(lines[0], ",score"), lines[0])
+	require.True(t, strings.HasSuffix(lines[1], ",900000.0"), lines[1])
+}
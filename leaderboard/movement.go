@@ -0,0 +1,93 @@
+package leaderboard
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/config"
+
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+)
+
+// referenceBoard holds the positions at the start of the current reference period.
+type referenceBoard struct {
+	at           time.Time
+	participants map[string]int
+	blacklisted  map[string]int
+}
+
+// trackMovement sets the position changes of newly ranked participants, by comparing them
+// to the previous board and to the board at the reference time, and the score changes
+// since the previous update. It returns the reference time, formatted like LastUpdate. The
+// caller holds updateMu.
+func (s *Service) trackMovement(include []Participant, exclude []Participant, now time.Time) string {
+	s.mu.RLock()
+	previous := referenceBoard{
+		participants: positionsByKey(s.board.Participants),
+		blacklisted:  positionsByKey(s.board.blacklisted),
+	}
+	s.mu.RUnlock()
+
+	period := s.cfg.ReferencePeriod
+	if period <= 0 {
+		period = config.DefaultReferencePeriod
+	}
+	if at := now.UTC().Truncate(period); !at.Equal(s.reference.at) {
+		s.reference = s.referenceAt(at, previous)
+	}
+
+	scores := make(map[string]decimal.Decimal, len(include)+len(exclude))
+	setMovement(include, previous.participants, s.reference.participants, s.scores, scores)
+	setMovement(exclude, previous.blacklisted, s.reference.blacklisted, s.scores, scores)
+	s.scores = scores
+	return fmt.Sprintf("%d", s.reference.at.Unix())
+}
+
+// referenceAt returns the positions at a reference time: those of the last snapshot taken
+// by then, or else those of the previous board, which was in place at that time unless the
+// service has just started.
+func (s *Service) referenceAt(at time.Time, previous referenceBoard) referenceBoard {
+	previous.at = at
+	if s.snapshots == nil {
+		return previous
+	}
+	snap, err := s.snapshots.SnapshotBefore(s.ID(), at)
+	if err != nil {
+		log.WithError(err).WithField("competition", s.ID()).Warn("Failed to load reference snapshot, using the previous board")
+		return previous
+	}
+	if snap == nil {
+		return previous
+	}
+	return referenceBoard{
+		at:           at,
+		participants: positionsByKey(snap.Participants),
+		blacklisted:  positionsByKey(snap.Blacklisted),
+	}
+}
+
+func setMovement(participants []Participant, previous map[string]int, reference map[string]int, lastScores map[string]decimal.Decimal, scores map[string]decimal.Decimal) {
+	for i, p := range participants {
+		if position, found := previous[p.PublicKey]; found {
+			participants[i].PreviousPosition = position
+			participants[i].PositionChange = position - p.Position
+		}
+		if position, found := reference[p.PublicKey]; found {
+			participants[i].ReferencePosition = position
+			participants[i].ReferenceChange = position - p.Position
+		}
+		if score, found := lastScores[p.PublicKey]; found {
+			participants[i].ScoreChange = p.sortNum.Sub(score).String()
+		}
+		scores[p.PublicKey] = p.sortNum
+	}
+}
+
+func positionsByKey(participants []Participant) map[string]int {
+	positions := make(map[string]int, len(participants))
+	for _, p := range participants {
+		positions[p.PublicKey] = p.Position
+	}
+	return positions
+}
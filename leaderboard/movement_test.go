@@ -0,0 +1,54 @@
+package leaderboard_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParticipantsCarryTheirMovement(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	// Every update starts a new reference period, so the reference is the previous board
+	cfg.ReferencePeriod = time.Nanosecond
+	host := leaderboard.NewHost(cfg)
+	host.Start()
+	defer host.Stop()
+
+	board := currentBoard(t, host.Default())
+	require.Equal(t, []string{"p2", "p1"}, publicKeys(board))
+	for _, p := range board.Participants {
+		require.Zero(t, p.PreviousPosition)
+		require.Zero(t, p.PositionChange)
+		require.Empty(t, p.ScoreChange)
+	}
+	require.NotEmpty(t, board.ReferenceTime)
+
+	// Reversing the ranking moves p1 up and p2 down
+	next := cfg
+	next.AlgorithmConfig = map[string]string{"metric": "realisedPnL", "ranker": "asc"}
+	host.Reload(next)
+	host.Default().Recompute()
+	require.Eventually(t, func() bool {
+		return publicKeys(currentBoard(t, host.Default()))[0] == "p1"
+	}, time.Second, 5*time.Millisecond)
+
+	board = currentBoard(t, host.Default())
+	p1, p2 := board.Participants[0], board.Participants[1]
+	require.Equal(t, 2, p1.PreviousPosition)
+	require.Equal(t, 1, p1.PositionChange)
+	require.Equal(t, 1, p2.PreviousPosition)
+	require.Equal(t, -1, p2.PositionChange)
+	require.Equal(t, 2, p1.ReferencePosition)
+	require.Equal(t, 1, p1.ReferenceChange)
+	require.NotEmpty(t, p1.ScoreChange)
+
+	csv, err := host.Default().CsvLeaderboard("", 0, 0, false, leaderboard.Sort{})
+	require.NoError(t, err)
+	lines := strings.Split(string(csv), "\n")
+	require.Contains(t, lines[0], "previous_position,position_change,reference_position,reference_change,score_change")
+	require.True(t, strings.HasPrefix(lines[1], "1,") && strings.Contains(lines[1], ",2,1,2,1,"), lines[1])
+}
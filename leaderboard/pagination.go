@@ -0,0 +1,284 @@
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vegaprotocol/topgun-service/datanode"
+)
+
+// pageSize is the number of edges requested per page of a connection.
+const pageSize = 50
+
+// forEachPage calls fetch with successive pages of a connection, starting at start, until
+// the connection reports no next page. A connection that does not move its cursor forward
+// is an error rather than an endless loop.
+func forEachPage(start Pagination, fetch func(pagination Pagination) (PageInfo, error)) error {
+	pagination := start
+	for {
+		info, err := fetch(pagination)
+		if err != nil {
+			return err
+		}
+		if !info.NextPage {
+			return nil
+		}
+		if info.EndCursor == "" || info.EndCursor == pagination.After {
+			return fmt.Errorf("connection has more pages but did not advance past cursor %q", pagination.After)
+		}
+		pagination.After = info.EndCursor
+	}
+}
+
+// partyConnection describes a connection on a party node that can be fetched page by page.
+type partyConnection struct {
+	// field and args are the GraphQL field name and its arguments other than pagination.
+	field string
+	args  string
+	// node is the selection on each node, the union of the fields the algorithms use.
+	node string
+	// pageInfo returns the page info of the connection on a fetched party.
+	pageInfo func(p *Party) PageInfo
+	// merge appends the edges of the connection in src to dst and takes its page info.
+	merge func(dst, src *Party)
+}
+
+// selection returns the connection's GraphQL selection, with the extra arguments given.
+func (c partyConnection) selection(extraArgs ...string) string {
+	args := []string{}
+	if c.args != "" {
+		args = append(args, c.args)
+	}
+	args = append(args, extraArgs...)
+	field := c.field
+	if len(args) > 0 {
+		field += "(" + strings.Join(args, ", ") + ")"
+	}
+	return fmt.Sprintf(`%s {
+			edges { node { %s } }
+			pageInfo { hasNextPage endCursor }
+		  }`, field, c.node)
+}
+
+const assetSelection = "asset { id name symbol decimals }"
+
+// partyConnections holds every party connection the algorithms query, by name.
+var partyConnections = map[string]partyConnection{
+	"accounts": {
+		field:    "accountsConnection",
+		node:     "type balance " + assetSelection,
+		pageInfo: func(p *Party) PageInfo { return p.AccountsConnection.PageInfo },
+		merge: func(dst, src *Party) {
+			dst.AccountsConnection.Edges = append(dst.AccountsConnection.Edges, src.AccountsConnection.Edges...)
+			dst.AccountsConnection.PageInfo = src.AccountsConnection.PageInfo
+		},
+	},
+	"deposits": {
+		field:    "depositsConnection",
+		node:     "id amount status createdTimestamp creditedTimestamp " + assetSelection,
+		pageInfo: func(p *Party) PageInfo { return p.DepositsConnection.PageInfo },
+		merge: func(dst, src *Party) {
+			dst.DepositsConnection.Edges = append(dst.DepositsConnection.Edges, src.DepositsConnection.Edges...)
+			dst.DepositsConnection.PageInfo = src.DepositsConnection.PageInfo
+		},
+	},
+	"withdrawals": {
+		field:    "withdrawalsConnection",
+		node:     "amount status createdTimestamp creditedTimestamp " + assetSelection,
+		pageInfo: func(p *Party) PageInfo { return p.WithdrawalsConnection.PageInfo },
+		merge: func(dst, src *Party) {
+			dst.WithdrawalsConnection.Edges = append(dst.WithdrawalsConnection.Edges, src.WithdrawalsConnection.Edges...)
+			dst.WithdrawalsConnection.PageInfo = src.WithdrawalsConnection.PageInfo
+		},
+	},
+	// transfers are the transfers received by the party
+	"transfers": {
+		field:    "transfersConnection",
+		args:     "direction: To",
+		node:     "id amount timestamp " + assetSelection,
+		pageInfo: func(p *Party) PageInfo { return p.TransfersConnection.PageInfo },
+		merge:    mergeTransfers,
+	},
+	// allTransfers are the transfers sent or received by the party
+	"allTransfers": {
+		field:    "transfersConnection",
+		node:     "id amount timestamp " + assetSelection,
+		pageInfo: func(p *Party) PageInfo { return p.TransfersConnection.PageInfo },
+		merge:    mergeTransfers,
+	},
+	"positions": {
+		field:    "positionsConnection",
+		node:     "market { id } openVolume realisedPNL unrealisedPNL averageEntryPrice",
+		pageInfo: func(p *Party) PageInfo { return p.PositionsConnection.PageInfo },
+		merge: func(dst, src *Party) {
+			dst.PositionsConnection.Edges = append(dst.PositionsConnection.Edges, src.PositionsConnection.Edges...)
+			dst.PositionsConnection.PageInfo = src.PositionsConnection.PageInfo
+		},
+	},
+	"rewards": {
+		field:    "rewardsConnection",
+		node:     "amount marketId rewardType receivedAt " + assetSelection,
+		pageInfo: func(p *Party) PageInfo { return p.RewardsConnection.PageInfo },
+		merge: func(dst, src *Party) {
+			dst.RewardsConnection.Edges = append(dst.RewardsConnection.Edges, src.RewardsConnection.Edges...)
+			dst.RewardsConnection.PageInfo = src.RewardsConnection.PageInfo
+		},
+	},
+	"votes": {
+		field:    "votesConnection",
+		node:     "proposalId vote { value datetime }",
+		pageInfo: func(p *Party) PageInfo { return p.VotesConnection.PageInfo },
+		merge: func(dst, src *Party) {
+			dst.VotesConnection.Edges = append(dst.VotesConnection.Edges, src.VotesConnection.Edges...)
+			dst.VotesConnection.PageInfo = src.VotesConnection.PageInfo
+		},
+	},
+	"liquidityProvisions": {
+		field: "liquidityProvisionsConnection",
+		node: `id market { id } commitmentAmount fee createdAt status reference
+				buys { liquidityOrder { reference proportion offset } }
+				sells { liquidityOrder { reference proportion offset } }`,
+		pageInfo: func(p *Party) PageInfo { return p.LPsConnection.PageInfo },
+		merge: func(dst, src *Party) {
+			dst.LPsConnection.Edges = append(dst.LPsConnection.Edges, src.LPsConnection.Edges...)
+			dst.LPsConnection.PageInfo = src.LPsConnection.PageInfo
+		},
+	},
+}
+
+func mergeTransfers(dst, src *Party) {
+	dst.TransfersConnection.Edges = append(dst.TransfersConnection.Edges, src.TransfersConnection.Edges...)
+	dst.TransfersConnection.PageInfo = src.TransfersConnection.PageInfo
+}
+
+// partyFragment builds the partyFields fragment selecting the named party connections, each
+// with its page info so that longer connections can be followed up.
+func partyFragment(names ...string) string {
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+	var selection strings.Builder
+	for _, name := range sorted {
+		c, found := partyConnections[name]
+		if !found {
+			panic(fmt.Sprintf("unknown party connection: %s", name))
+		}
+		selection.WriteString("\n\t  ")
+		selection.WriteString(c.selection())
+	}
+
+	return fmt.Sprintf(`fragment partyFields on Party {
+	  id%s
+	}`, selection.String())
+}
+
+// partiesQuery builds a paginated partiesConnection query over every party on the network.
+func partiesQuery(names ...string) string {
+	return fmt.Sprintf(`query ($pagination: Pagination!) {
+	partiesConnection(pagination: $pagination) {
+	  edges {
+		node {
+		  ...partyFields
+		}
+	  }
+	  pageInfo {
+		hasNextPage
+		endCursor
+	  }
+	}
+  }
+  %s`, partyFragment(names...))
+}
+
+// connectionPageQuery builds the query for the following pages of one connection of a party.
+func connectionPageQuery(c partyConnection) string {
+	return fmt.Sprintf(`query ($partyId: ID!, $pagination: Pagination) {
+	party(id: $partyId) {
+	  id
+	  %s
+	}
+  }`, c.selection("pagination: $pagination"))
+}
+
+// fetchAllParties returns every party on the network with all the edges of the named
+// connections. Only algorithms that rank public keys without a verified social need this,
+// the others fetch the registered parties with fetchParties.
+func (s *Service) fetchAllParties(ctx context.Context, names ...string) ([]PartiesEdge, error) {
+	partyEdges := []PartiesEdge{}
+	query := partiesQuery(names...)
+	err := forEachPage(Pagination{First: pageSize}, func(pagination Pagination) (PageInfo, error) {
+		connection, err := getPartiesConnection(
+			ctx,
+			s.dataNode,
+			query,
+			map[string]interface{}{"pagination": pagination},
+		)
+		if err != nil {
+			return PageInfo{}, err
+		}
+		partyEdges = append(partyEdges, connection.Edges...)
+		return connection.PageInfo, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range partyEdges {
+		if err := s.followConnections(ctx, &partyEdges[i].Party, names); err != nil {
+			return nil, err
+		}
+	}
+	return partyEdges, nil
+}
+
+// followConnections fetches the remaining pages of each named connection of a party with
+// more edges than fit in the first page, so no connection is silently truncated.
+func (s *Service) followConnections(ctx context.Context, party *Party, names []string) error {
+	for _, name := range names {
+		c := partyConnections[name]
+		first := c.pageInfo(party)
+		if !first.NextPage {
+			continue
+		}
+		if first.EndCursor == "" {
+			return fmt.Errorf("failed to get %s of party %s: no cursor for the next page", c.field, party.ID)
+		}
+		query := connectionPageQuery(c)
+		start := Pagination{First: pageSize, After: first.EndCursor}
+		err := forEachPage(start, func(pagination Pagination) (PageInfo, error) {
+			page, err := getParty(
+				ctx,
+				s.dataNode,
+				query,
+				map[string]interface{}{"partyId": party.ID, "pagination": pagination},
+			)
+			if err != nil {
+				return PageInfo{}, err
+			}
+			c.merge(party, &page)
+			return c.pageInfo(&page), nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get %s of party %s: %w", c.field, party.ID, err)
+		}
+	}
+	return nil
+}
+
+type PartyResponse struct {
+	Party Party `json:"party"`
+}
+
+func getParty(
+	ctx context.Context,
+	client *datanode.Client,
+	gqlQuery string,
+	vars map[string]interface{},
+) (Party, error) {
+	var response PartyResponse
+	if err := client.Run(ctx, gqlQuery, vars, &response); err != nil {
+		return Party{}, err
+	}
+	return response.Party, nil
+}
@@ -0,0 +1,149 @@
+package leaderboard_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vegaprotocol/topgun-service/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+// p1 has three pages of positions, the first of them returned with the party.
+const testPagedParties = `{"data": {"partiesConnection": {
+	"edges": [
+		{"node": {"id": "p1", "positionsConnection": {
+			"edges": [{"node": {"market": {"id": "m1"}, "openVolume": "1", "realisedPNL": "1000", "unrealisedPNL": "0"}}],
+			"pageInfo": {"hasNextPage": true, "endCursor": "p1-positions-1"}
+		}}},
+		{"node": {"id": "p2", "positionsConnection": {
+			"edges": [{"node": {"market": {"id": "m1"}, "openVolume": "1", "realisedPNL": "1500", "unrealisedPNL": "0"}}]
+		}}}
+	]
+}}}`
+
+var testPositionPages = map[string]string{
+	"p1-positions-1": `{"data": {"party": {"id": "p1", "positionsConnection": {
+		"edges": [{"node": {"market": {"id": "m2"}, "openVolume": "1", "realisedPNL": "700", "unrealisedPNL": "0"}}],
+		"pageInfo": {"hasNextPage": true, "endCursor": "p1-positions-2"}
+	}}}}`,
+	"p1-positions-2": `{"data": {"party": {"id": "p1", "positionsConnection": {
+		"edges": [{"node": {"market": {"id": "m1"}, "openVolume": "1", "realisedPNL": "300", "unrealisedPNL": "0"}}],
+		"pageInfo": {"hasNextPage": false}
+	}}}}`,
+	"p1-positions-3": `{"data": {"party": {"id": "p1", "positionsConnection": {
+		"edges": [],
+		"pageInfo": {"hasNextPage": true, "endCursor": "p1-positions-3"}
+	}}}}`,
+}
+
+// Every party on the network is served over two pages, for the algorithms that rank all public keys.
+var testNetworkPages = map[string]string{
+	"": `{"data": {"partiesConnection": {
+		"edges": [
+			{"node": {"id": "k1", "rewardsConnection": {"edges": [
+				{"node": {"amount": "40", "asset": {"id": "a1"}, "rewardType": "ACCOUNT_TYPE_REWARD_MAKER_RECEIVED_FEES", "receivedAt": "2023-03-17T12:00:00Z"}}
+			]}}}
+		],
+		"pageInfo": {"hasNextPage": true, "endCursor": "parties-1"}
+	}}}`,
+	"parties-1": `{"data": {"partiesConnection": {
+		"edges": [
+			{"node": {"id": "k2", "rewardsConnection": {"edges": [
+				{"node": {"amount": "60", "asset": {"id": "a1"}, "rewardType": "ACCOUNT_TYPE_REWARD_MAKER_RECEIVED_FEES", "receivedAt": "2023-03-17T12:00:00Z"}}
+			]}}}
+		],
+		"pageInfo": {"hasNextPage": false, "endCursor": "parties-2"}
+	}}}`,
+}
+
+func requestCursor(req testGraphQLRequest) string {
+	pagination, _ := req.Variables["pagination"].(map[string]interface{})
+	after, _ := pagination["after"].(string)
+	return after
+}
+
+// newPagedTestConfig serves the pipeline test socials, with party data split into pages.
+// It also returns the cursors requested so far, prefixed with the party ID for follow-up pages.
+func newPagedTestConfig(t *testing.T, algorithm string, parties string) (config.Config, func() []string) {
+	var (
+		mu      sync.Mutex
+		cursors []string
+	)
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL", "precision": "0"})
+	cfg.Algorithm = algorithm
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var req testGraphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(req.Query, "assetsConnection") {
+			w.Write([]byte(testAssetsResponse))
+			return
+		}
+
+		cursor := requestCursor(req)
+		mu.Lock()
+		if partyID, found := req.Variables["partyId"]; found {
+			cursors = append(cursors, partyID.(string)+"@"+cursor)
+		} else if strings.Contains(req.Query, "partiesConnection") {
+			cursors = append(cursors, "@"+cursor)
+		}
+		mu.Unlock()
+
+		switch {
+		case strings.Contains(req.Query, "p0: party("):
+			writeTestPartyBatch(t, w, parties, req.Variables)
+		case strings.Contains(req.Query, "party(id: $partyId)"):
+			w.Write([]byte(testPositionPages[cursor]))
+		default:
+			w.Write([]byte(testNetworkPages[cursor]))
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	gqlURL, err := url.Parse(srv.URL + "/graphql")
+	require.NoError(t, err)
+	cfg.VegaGraphQLURL = gqlURL
+
+	return cfg, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, cursors...)
+	}
+}
+
+func TestFetchFollowsConnectionPages(t *testing.T) {
+	cfg, cursors := newPagedTestConfig(t, "ByPipeline", testPagedParties)
+	board := rankedBoard(t, cfg)
+	require.Equal(t, "active", board.Status)
+
+	// p1 has 1000 + 700 + 300 over three pages of positions
+	require.Equal(t, []string{"p1", "p2"}, publicKeys(board))
+	require.Equal(t, []string{"2000"}, board.Participants[0].Data)
+	require.Equal(t, []string{"1500"}, board.Participants[1].Data)
+	require.Equal(t, []string{"p1@p1-positions-1", "p1@p1-positions-2"}, cursors())
+}
+
+func TestFetchFailsOnStuckCursor(t *testing.T) {
+	stuck := strings.Replace(testPagedParties, `"endCursor": "p1-positions-1"`, `"endCursor": "p1-positions-3"`, 1)
+	cfg, cursors := newPagedTestConfig(t, "ByPipeline", stuck)
+	board := rankedBoard(t, cfg)
+
+	// The cursor did not move, so the update fails rather than looping
+	require.Empty(t, board.Participants)
+	require.Equal(t, []string{"p1@p1-positions-3"}, cursors())
+}
+
+func TestFetchAllPartiesFollowsPages(t *testing.T) {
+	cfg, cursors := newPagedTestConfig(t, "ByPartyRewardsMakerReceivedPubkeys", testPagedParties)
+	board := rankedBoard(t, cfg)
+
+	require.Equal(t, []string{"k2", "k1"}, publicKeys(board))
+	require.Equal(t, []string{"@", "@parties-1"}, cursors())
+}
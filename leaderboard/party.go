@@ -2,13 +2,12 @@ package leaderboard
 
 import (
 	"context"
-	"math"
-	"net/http"
-	"strconv"
+	"fmt"
 	"time"
 
-	"github.com/machinebox/graphql"
+	"github.com/shopspring/decimal"
 	log "github.com/sirupsen/logrus"
+	"github.com/vegaprotocol/topgun-service/datanode"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
@@ -25,7 +24,8 @@ type AssetSource struct {
 }
 
 type AccountsConnection struct {
-	Edges []AccountsEdge `json:"edges"`
+	Edges    []AccountsEdge `json:"edges"`
+	PageInfo PageInfo       `json:"pageInfo"`
 }
 
 type AccountsEdge struct {
@@ -39,7 +39,8 @@ type Account struct {
 }
 
 type DepositsConnection struct {
-	Edges []DepositsEdge `json:"edges"`
+	Edges    []DepositsEdge `json:"edges"`
+	PageInfo PageInfo       `json:"pageInfo"`
 }
 
 type DepositsEdge struct {
@@ -56,7 +57,8 @@ type Deposit struct {
 }
 
 type WithdrawalsConnection struct {
-	Edges []WithdrawalsEdge `json:"edges"`
+	Edges    []WithdrawalsEdge `json:"edges"`
+	PageInfo PageInfo          `json:"pageInfo"`
 }
 
 type WithdrawalsEdge struct {
@@ -72,7 +74,8 @@ type Withdrawal struct {
 }
 
 type TransfersConnection struct {
-	Edges []TransfersEdge `json:"edges"`
+	Edges    []TransfersEdge `json:"edges"`
+	PageInfo PageInfo        `json:"pageInfo"`
 }
 
 type TransfersEdge struct {
@@ -104,7 +107,8 @@ type PositionsResponse struct {
 }
 
 type PositionsConnection struct {
-	Edges []PositionsEdge `json:"edges"`
+	Edges    []PositionsEdge `json:"edges"`
+	PageInfo PageInfo        `json:"pageInfo"`
 }
 
 type PositionsEdge struct {
@@ -119,13 +123,27 @@ type Position struct {
 	RealisedPNL       string `json:"realisedPNL"`
 	Party             Party  `json:"party"`
 	PartyID           string
-	Partysocial       string
-	PartytwitterID    int64
-	Partyblacklisted  bool
+	Partyidentity     verifier.Identity
+}
+
+// amounts returns the realised and unrealised PnL of the position in whole units of the
+// settlement asset, and its open volume.
+func (p Position) amounts(settlement Asset) (realised decimal.Decimal, unrealised decimal.Decimal, openVolume decimal.Decimal, err error) {
+	if realised, err = assetAmount(p.RealisedPNL, settlement); err != nil {
+		return realised, unrealised, openVolume, fmt.Errorf("realised PnL of %s: %w", p.Market.ID, err)
+	}
+	if unrealised, err = assetAmount(p.UnrealisedPNL, settlement); err != nil {
+		return realised, unrealised, openVolume, fmt.Errorf("unrealised PnL of %s: %w", p.Market.ID, err)
+	}
+	if openVolume, err = parseAmount(p.OpenVolume); err != nil {
+		return realised, unrealised, openVolume, fmt.Errorf("open volume of %s: %w", p.Market.ID, err)
+	}
+	return realised, unrealised, openVolume, nil
 }
 
 type RewardsConnection struct {
-	Edges []RewardsEdge `json:"edges"`
+	Edges    []RewardsEdge `json:"edges"`
+	PageInfo PageInfo      `json:"pageInfo"`
 }
 
 type RewardsEdge struct {
@@ -154,7 +172,8 @@ type Trade struct {
 }
 
 type VotesConnection struct {
-	Edges []VotesEdge `json:"edges"`
+	Edges    []VotesEdge `json:"edges"`
+	PageInfo PageInfo    `json:"pageInfo"`
 }
 
 type VotesEdge struct {
@@ -186,7 +205,8 @@ type Sells struct {
 }
 
 type LiquidityProvisionsConnection struct {
-	Edges []LiquidityProvisionsEdge `json:"edges"`
+	Edges    []LiquidityProvisionsEdge `json:"edges"`
+	PageInfo PageInfo                  `json:"pageInfo"`
 }
 
 type LiquidityProvisionsEdge struct {
@@ -235,9 +255,7 @@ type Party struct {
 	LPsConnection         LiquidityProvisionsConnection `json:"liquidityProvisionsConnection"`
 	PositionsConnection   PositionsConnection           `json:"positionsConnection"`
 	RewardsConnection     RewardsConnection             `json:"rewardsConnection"`
-	social                string
-	twitterID             int64
-	blacklisted           bool
+	identity              verifier.Identity
 }
 
 type Market struct {
@@ -261,176 +279,88 @@ func hasString(ss []string, s string) bool {
 	return false
 }
 
-func (p *Party) Balance(assetId string, decimalPlaces int, accountTypes ...string) float64 {
-	var accu float64
-	accu = 0
-
+// Balance returns the total of the party's accounts of the given types in an asset, in whole units.
+func (p *Party) Balance(asset Asset, accountTypes ...string) (decimal.Decimal, error) {
+	accu := decimal.Zero
 	for _, acc := range p.AccountsConnection.Edges {
-		if acc.Account.Asset.Id == assetId && hasString(accountTypes, acc.Account.Type) {
-			v, err := strconv.ParseFloat(acc.Account.Balance, 64)
+		if acc.Account.Asset.Id == asset.Id && hasString(accountTypes, acc.Account.Type) {
+			balance, err := parseAmount(acc.Account.Balance)
 			if err != nil {
-				log.WithError(err).Errorf(
-					"Failed to parse %s/%s balance [Balance]", assetId, accountTypes)
-				return 0
+				return decimal.Zero, fmt.Errorf("balance of %s: %w", p.ID, err)
 			}
-			accu += v
+			accu = accu.Add(balance)
 		}
 	}
-	if accu != 0 && decimalPlaces > 0 {
-		dpMultiplier := math.Pow(10, float64(decimalPlaces))
-		accu = accu / dpMultiplier
-	}
-	return accu
+	return accu.Shift(-int32(asset.Decimals)), nil
 }
 
-func (p *Party) CalculateTotalDeposits(asset string, decimalPlaces int) float64 {
-	// Total deposits made in asset
-	var total float64
-	total = 0
+// CalculateTotalDeposits returns the total of the party's finalized deposits in an asset, in whole units.
+func (p *Party) CalculateTotalDeposits(asset Asset) (decimal.Decimal, error) {
+	total := decimal.Zero
 	for _, d := range p.DepositsConnection.Edges {
-		if d.Deposit.Asset.Id == asset && d.Deposit.Status == "Finalized" {
-			amount, err := strconv.ParseFloat(d.Deposit.Amount, 10)
+		if d.Deposit.Asset.Id == asset.Id && d.Deposit.Status == "Finalized" {
+			amount, err := parseAmount(d.Deposit.Amount)
 			if err != nil {
-				log.WithError(err).Error("Cannot parse the found epoch in delegation")
+				return decimal.Zero, fmt.Errorf("deposit %s: %w", d.Deposit.Id, err)
 			}
-			//log.Infof("Amount raw %s, converted: %f", d.Amount, amount)
-			total += amount
+			total = total.Add(amount)
 		}
 	}
-	if total != 0 && decimalPlaces > 0 {
-		dpMultiplier := math.Pow(10, float64(decimalPlaces))
-		total = total / dpMultiplier
-		log.Infof("Amount total %f, dpMultiplier: %f", total, dpMultiplier)
-	}
-	return total
+	return total.Shift(-int32(asset.Decimals)), nil
 }
 
 type PartiesResponse struct {
 	PartiesConnection PartiesConnection `json:"partiesConnection"`
 }
 
-func getParties(
-	ctx context.Context,
-	gqlURL string,
-	gqlQuery string,
-	vars map[string]string,
-	cli *http.Client,
-) ([]PartiesEdge, error) {
-
-	if cli == nil {
-		cli = &http.Client{Timeout: time.Second * 180}
-	}
-	client := graphql.NewClient(gqlURL, graphql.WithHTTPClient(cli))
-	req := graphql.NewRequest(gqlQuery)
-	req.Header.Set("Cache-Control", "no-cache")
-	for key, value := range vars {
-		req.Var(key, value)
-	}
-	var response PartiesResponse
-	if err := client.Run(ctx, req, &response); err != nil {
-		return nil, err
-	}
-	return response.PartiesConnection.Edges, nil
-}
-
 func getPartiesConnection(
 	ctx context.Context,
-	gqlURL string,
+	client *datanode.Client,
 	gqlQuery string,
 	vars map[string]interface{},
-	cli *http.Client,
 ) (PartiesConnection, error) {
-
-	if cli == nil {
-		cli = &http.Client{Timeout: time.Second * 180}
-	}
-	client := graphql.NewClient(gqlURL, graphql.WithHTTPClient(cli))
-	req := graphql.NewRequest(gqlQuery)
-	req.Header.Set("Cache-Control", "no-cache")
-	for key, value := range vars {
-		req.Var(key, value)
-	}
 	var response PartiesResponse
-	if err := client.Run(ctx, req, &response); err != nil {
+	if err := client.Run(ctx, gqlQuery, vars, &response); err != nil {
 		return PartiesConnection{}, err
 	}
 	return response.PartiesConnection, nil
 }
 
-func getPageInfo(
-	ctx context.Context,
-	gqlURL string,
-	gqlQuery string,
-	vars map[string]string,
-	cli *http.Client,
-) (PageInfo, error) {
-
-	if cli == nil {
-		cli = &http.Client{Timeout: time.Second * 180}
-	}
-	client := graphql.NewClient(gqlURL, graphql.WithHTTPClient(cli))
-	req := graphql.NewRequest(gqlQuery)
-	req.Header.Set("Cache-Control", "no-cache")
-	for key, value := range vars {
-		req.Var(key, value)
-	}
-	var response PartiesResponse
-	if err := client.Run(ctx, req, &response); err != nil {
-		return PageInfo{}, err
-	}
-	return response.PartiesConnection.PageInfo, nil
-}
-
 func getPositions(
 	ctx context.Context,
-	gqlURL string,
+	client *datanode.Client,
 	gqlQuery string,
-	vars map[string]string,
-	cli *http.Client,
-) ([]PositionsEdge, error) {
-
-	if cli == nil {
-		cli = &http.Client{Timeout: time.Second * 180}
-	}
-	client := graphql.NewClient(gqlURL, graphql.WithHTTPClient(cli))
-	req := graphql.NewRequest(gqlQuery)
-	req.Header.Set("Cache-Control", "no-cache")
-	for key, value := range vars {
-		req.Var(key, value)
-	}
+	vars map[string]interface{},
+) (PositionsConnection, error) {
 	var response PositionsResponse
-	if err := client.Run(ctx, req, &response); err != nil {
-		return nil, err
+	if err := client.Run(ctx, gqlQuery, vars, &response); err != nil {
+		return PositionsConnection{}, err
 	}
-	return response.PositionsConnection.Edges, nil
+	return response.PositionsConnection, nil
 }
 
 func socialParties(socials map[string]verifier.Social, parties []PartiesEdge) []Party {
 	// Must show in the leaderboard ALL parties registered in the socials list, regardless of whether they exist in Vega
+	byID := make(map[string]Party, len(parties))
+	for _, p := range parties {
+		if _, found := byID[p.Party.ID]; !found {
+			byID[p.Party.ID] = p.Party
+		}
+	}
 	sp := make([]Party, 0, len(socials))
 	for partyID, social := range socials {
-		found := false
-		for _, p := range parties {
-			if p.Party.ID == partyID {
-				log.WithFields(log.Fields{
-					"partyID":       partyID,
-					"social":        social,
-					"account_count": len(p.Party.AccountsConnection.Edges),
-				}).Debug("Social (found)")
-				p.Party.social = social.TwitterHandle
-				p.Party.twitterID = social.TwitterUserID
-				p.Party.blacklisted = social.IsBlacklisted
-				sp = append(sp, p.Party)
-				found = true
-				break
-			}
-		}
-		if !found {
+		if p, found := byID[partyID]; found {
+			log.WithFields(log.Fields{
+				"partyID":       partyID,
+				"social":        social,
+				"account_count": len(p.AccountsConnection.Edges),
+			}).Debug("Social (found)")
+			p.identity = social.Identity
+			sp = append(sp, p)
+		} else {
 			sp = append(sp, Party{
-				ID:          partyID,
-				social:      social.TwitterHandle,
-				twitterID:   social.TwitterUserID,
-				blacklisted: social.IsBlacklisted,
+				ID:       partyID,
+				identity: social.Identity,
 			})
 			log.WithFields(log.Fields{
 				"partyID":       partyID,
@@ -444,30 +374,26 @@ func socialParties(socials map[string]verifier.Social, parties []PartiesEdge) []
 
 func socialPositions(socials map[string]verifier.Social, positions []PositionsEdge) []Position {
 	// Must show in the leaderboard ALL parties registered in the socials list, regardless of whether they exist in Vega
+	byID := make(map[string]Position, len(positions))
+	for _, p := range positions {
+		if _, found := byID[p.Position.Party.ID]; !found {
+			byID[p.Position.Party.ID] = p.Position
+		}
+	}
 	sp := make([]Position, 0, len(socials))
 	for partyID, social := range socials {
-		found := false
-		for _, p := range positions {
-			if p.Position.Party.ID == partyID {
-				log.WithFields(log.Fields{
-					"partyID":       partyID,
-					"social":        social,
-					"account_count": len(p.Position.Party.AccountsConnection.Edges),
-				}).Debug("Social (found)")
-				p.Position.Party.social = social.TwitterHandle
-				p.Position.Party.twitterID = social.TwitterUserID
-				p.Position.Party.blacklisted = social.IsBlacklisted
-				sp = append(sp, p.Position)
-				found = true
-				break
-			}
-		}
-		if !found {
+		if p, found := byID[partyID]; found {
+			log.WithFields(log.Fields{
+				"partyID":       partyID,
+				"social":        social,
+				"account_count": len(p.Party.AccountsConnection.Edges),
+			}).Debug("Social (found)")
+			p.Party.identity = social.Identity
+			sp = append(sp, p)
+		} else {
 			sp = append(sp, Position{
-				PartyID:          partyID,
-				Partysocial:      social.TwitterHandle,
-				PartytwitterID:   social.TwitterUserID,
-				Partyblacklisted: social.IsBlacklisted,
+				PartyID:       partyID,
+				Partyidentity: social.Identity,
 			})
 			log.WithFields(log.Fields{
 				"partyID":       partyID,
@@ -0,0 +1,266 @@
+package leaderboard
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"github.com/vegaprotocol/topgun-service/verifier"
+)
+
+// The period aggregate algorithm splits the competition into periods and ranks parties by
+// an aggregate of their per-period scores. It takes the same algorithmConfig as the pipeline
+// algorithm, plus:
+//
+//	periods:   comma-separated period boundaries, e.g. 2023-02-25T10:30:00Z,2023-02-26T10:30:00Z
+//	aggregate: how per-period scores are combined (median, mean, min, sum)
+//
+// The metric is cumulative, e.g. realised PnL since the competition started. Once a boundary has
+// passed, the score of every party at the boundary is captured as the baseline period-<n>: from the
+// last snapshot taken at or before it, or the current score without one. The score for a period is
+// the difference between the party's score at its end and at its start, and the running period ends
+// now. Only the periods that have started are aggregated. A party missing from a boundary, e.g.
+// because it registered later, has a baseline of 0 until the first boundary it appears in, and
+// keeps its previous baseline at a later boundary it is missing from.
+const periodAggregateAlgorithmName = "ByPeriodAggregate"
+
+func init() {
+	RegisterAlgorithm(&periodAggregateAlgorithm{})
+}
+
+type periodAggregateAlgorithm struct{}
+
+func (a *periodAggregateAlgorithm) Name() string { return periodAggregateAlgorithmName }
+func (a *periodAggregateAlgorithm) RequiredConfig() []string {
+	return []string{"metric", "periods", "aggregate"}
+}
+
+func (a *periodAggregateAlgorithm) MetricNames() []string { return []string{"score"} }
+
+func (a *periodAggregateAlgorithm) ValidateConfig(algorithmConfig map[string]string) error {
+	_, err := parsePeriodAggregate(algorithmConfig)
+	return err
+}
+
+func (a *periodAggregateAlgorithm) Score(s *Service, socials map[string]verifier.Social) ([]Participant, error) {
+	pa, err := parsePeriodAggregate(s.cfg.AlgorithmConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse period aggregate: %w", err)
+	}
+	return pa.run(s, socials)
+}
+
+type periodAggregate struct {
+	pipeline  *pipeline
+	periods   []time.Time
+	aggregate string
+}
+
+func parsePeriodAggregate(cfg map[string]string) (*periodAggregate, error) {
+	p, err := parsePipeline(cfg)
+	if err != nil {
+		return nil, err
+	}
+	pa := &periodAggregate{
+		pipeline:  p,
+		aggregate: cfg["aggregate"],
+	}
+
+	if _, found := aggregates[pa.aggregate]; !found {
+		return nil, fmt.Errorf("invalid aggregate: %s (median, mean, min, sum)", pa.aggregate)
+	}
+	for _, item := range splitList(cfg["periods"]) {
+		boundary, err := parseTime(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid periods: %w", err)
+		}
+		if len(pa.periods) > 0 && !boundary.After(pa.periods[len(pa.periods)-1]) {
+			return nil, fmt.Errorf("invalid periods: %s is not after the previous boundary", item)
+		}
+		pa.periods = append(pa.periods, boundary)
+	}
+	if len(pa.periods) == 0 {
+		return nil, fmt.Errorf("missing periods")
+	}
+	return pa, nil
+}
+
+var aggregates = map[string]func([]decimal.Decimal) decimal.Decimal{
+	"median": median,
+	"mean": func(data []decimal.Decimal) decimal.Decimal {
+		return decimal.Avg(data[0], data[1:]...)
+	},
+	"min": func(data []decimal.Decimal) decimal.Decimal {
+		return decimal.Min(data[0], data[1:]...)
+	},
+	"sum": func(data []decimal.Decimal) decimal.Decimal {
+		return decimal.Sum(data[0], data[1:]...)
+	},
+}
+
+func (pa *periodAggregate) run(s *Service, socials map[string]verifier.Social) ([]Participant, error) {
+	p := pa.pipeline
+	sc, err := p.scope(s)
+	if err != nil {
+		return nil, err
+	}
+	partyEdges, err := p.fetch(s.ctx, s, socials)
+	if err != nil {
+		return nil, err
+	}
+	sParties := socialParties(socials, partyEdges)
+
+	current := make(map[string]decimal.Decimal, len(sParties))
+	for i := range sParties {
+		score, err := p.score(&sParties[i], sc)
+		if err != nil {
+			return nil, err
+		}
+		current[sParties[i].ID] = score
+	}
+	s.cumulative = current
+
+	// Cumulative scores at every boundary passed so far
+	now := time.Now()
+	boundaries := []map[string]decimal.Decimal{}
+	for i, boundary := range pa.periods {
+		if now.Before(boundary) {
+			break
+		}
+		scores, err := s.periodBoundaryScores(i+1, boundary, current)
+		if err != nil {
+			return nil, err
+		}
+		boundaries = append(boundaries, scores)
+	}
+
+	aggregate := aggregates[pa.aggregate]
+	participants := []Participant{}
+	for _, party := range sParties {
+		periodScores := startedPeriodScores(party.ID, boundaries, current[party.ID])
+		score := aggregate(periodScores)
+		if !p.included(score) {
+			continue
+		}
+		breakdown, err := p.breakdown(&party, sc, current[party.ID])
+		if err != nil {
+			return nil, err
+		}
+		breakdown.Periods = pa.periodBreakdowns(s, periodScores)
+		breakdown.Notes = append(breakdown.Notes, fmt.Sprintf(
+			"the terms add up to the cumulative score, the score is the %s of the period scores", pa.aggregate))
+		breakdown.Score = score.String()
+		t := time.Now().UTC()
+		participants = append(participants, Participant{
+			PublicKey:   party.ID,
+			Data:        []string{p.formatValue(score, sc)},
+			Metrics:     Metrics{p.scoreMetric(score, sc)},
+			sortNum:     score,
+			firstAction: p.firstAction(&party, sc),
+			breakdown:   breakdown,
+			CreatedAt:   t,
+			UpdatedAt:   t,
+		})
+	}
+	p.rank(participants)
+	return participants, nil
+}
+
+// startedPeriodScores returns a party's scores for the periods started so far: one more than
+// the boundaries passed, the last being the running period. Until the first boundary the party
+// appears in its baseline is 0, and at a later boundary it is missing from its previous baseline
+// is kept, so that the gap does not move its whole cumulative score into one period.
+func startedPeriodScores(partyID string, boundaries []map[string]decimal.Decimal, current decimal.Decimal) []decimal.Decimal {
+	scores := make([]decimal.Decimal, 0, len(boundaries)+1)
+	previous := decimal.Zero
+	for _, boundary := range boundaries {
+		score, found := boundary[partyID]
+		if !found {
+			score = previous
+		}
+		scores = append(scores, score.Sub(previous))
+		previous = score
+	}
+	return append(scores, current.Sub(previous))
+}
+
+// periodBreakdowns pairs the scores of the periods with their bounds. The first period starts
+// with the competition and the last ends with it.
+func (pa *periodAggregate) periodBreakdowns(s *Service, scores []decimal.Decimal) []PeriodBreakdown {
+	bounds := append(append([]time.Time{s.cfg.StartTime}, pa.periods...), s.cfg.EndTime)
+	periods := make([]PeriodBreakdown, len(scores))
+	for i, score := range scores {
+		periods[i] = PeriodBreakdown{
+			Start: bounds[i].UTC().Format(time.RFC3339),
+			End:   bounds[i+1].UTC().Format(time.RFC3339),
+			Score: score.String(),
+		}
+	}
+	return periods
+}
+
+// periodBoundaryScores returns the cumulative scores at the end of period n. If the boundary
+// has not been captured yet, the scores of the last snapshot at or before it are stored as its
+// baseline, or the current scores if there is no such snapshot.
+func (s *Service) periodBoundaryScores(n int, boundary time.Time, current map[string]decimal.Decimal) (map[string]decimal.Decimal, error) {
+	name := fmt.Sprintf("period-%d", n)
+	if s.baselines == nil {
+		return nil, fmt.Errorf("baseline %s: no baseline store configured", name)
+	}
+	b, err := s.baselines.LoadBaseline(s.ID(), name)
+	if err != nil {
+		return nil, err
+	}
+	if b != nil {
+		return b.Scores()
+	}
+
+	logger := log.WithFields(log.Fields{"competition": s.ID(), "baseline": name})
+	scores, found, err := s.cumulativeScoresAt(boundary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture baseline %s: %w", name, err)
+	}
+	if !found {
+		logger.Warn("No snapshot before the period boundary, capturing the current scores")
+		scores = current
+	}
+	b = &Baseline{
+		Competition: s.ID(),
+		Name:        name,
+		CapturedAt:  time.Now().UTC(),
+	}
+	for partyID, score := range scores {
+		b.Participants = append(b.Participants, Participant{
+			PublicKey: partyID,
+			Data:      []string{score.String()},
+			Score:     score.String(),
+		})
+	}
+	if err := s.baselines.SaveBaseline(*b); err != nil {
+		return nil, fmt.Errorf("failed to capture baseline %s: %w", name, err)
+	}
+	logger.WithField("participants", len(b.Participants)).Info("Captured period baseline")
+	return scores, nil
+}
+
+// cumulativeScoresAt returns the cumulative scores of the last snapshot taken at or before a
+// time. It returns false without snapshots, or if that snapshot has no cumulative scores.
+func (s *Service) cumulativeScoresAt(at time.Time) (map[string]decimal.Decimal, bool, error) {
+	if s.snapshots == nil {
+		return nil, false, nil
+	}
+	snap, err := s.snapshots.SnapshotBefore(s.ID(), at)
+	if err != nil || snap == nil || snap.Cumulative == nil {
+		return nil, false, err
+	}
+	scores := make(map[string]decimal.Decimal, len(snap.Cumulative))
+	for partyID, raw := range snap.Cumulative {
+		score, err := decimal.NewFromString(raw)
+		if err != nil {
+			return nil, false, fmt.Errorf("snapshot %d has an invalid score for %s: %w", snap.Sequence, partyID, err)
+		}
+		scores[partyID] = score
+	}
+	return scores, true, nil
+}
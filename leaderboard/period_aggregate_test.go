@@ -0,0 +1,185 @@
+package leaderboard_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriodAggregateValidation(t *testing.T) {
+	v := leaderboard.AlgorithmValidator{}
+
+	require.NoError(t, v.ValidateAlgorithm("ByPeriodAggregate", map[string]string{
+		"metric": "realisedPnL", "periods": "2023-03-17T10:00:00Z,2023-03-18T10:00:00Z", "aggregate": "median",
+	}))
+
+	invalid := []map[string]string{
+		{"metric": "realisedPnL", "periods": "2023-03-17T10:00:00Z", "aggregate": "mode"},
+		{"metric": "realisedPnL", "periods": "", "aggregate": "sum"},
+		{"metric": "realisedPnL", "periods": "tomorrow", "aggregate": "sum"},
+		{"metric": "realisedPnL", "periods": "2023-03-18T10:00:00Z,2023-03-17T10:00:00Z", "aggregate": "sum"},
+		{"metric": "luck", "periods": "2023-03-17T10:00:00Z", "aggregate": "sum"},
+	}
+	for _, cfg := range invalid {
+		require.Error(t, v.ValidateAlgorithm("ByPeriodAggregate", cfg), cfg)
+	}
+}
+
+func TestPeriodAggregateScores(t *testing.T) {
+	past := time.Now().Add(-time.Hour).UTC().Format("2006-01-02T15:04:05Z")
+	future := time.Now().Add(time.Hour).UTC().Format("2006-01-02T15:04:05Z")
+
+	cases := map[string]map[string]string{
+		// p1 has 8000 now and 8000 at the boundary, p2 9000 now and 4000 at the boundary.
+		// Periods: p1 [8000, 0], p2 [4000, 5000], p3 has no positions. The period after the
+		// future boundary has not started and is not aggregated.
+		"median": {"p2": "4500", "p1": "4000", "p3": "0"},
+		"sum":    {"p2": "9000", "p1": "8000", "p3": "0"},
+		"min":    {"p2": "4000", "p1": "0", "p3": "0"},
+		"mean":   {"p2": "4500", "p1": "4000", "p3": "0"},
+	}
+	for aggregate, expected := range cases {
+		baselines := leaderboard.NewFileBaselineStore(t.TempDir())
+		require.NoError(t, baselines.SaveBaseline(leaderboard.Baseline{
+			Competition: "default",
+			Name:        "period-1",
+			Participants: []leaderboard.Participant{
+				{PublicKey: "p1", Data: []string{"8000"}},
+				{PublicKey: "p2", Data: []string{"4000"}},
+			},
+		}))
+
+		cfg := newPipelineTestConfig(t, map[string]string{
+			"metric":    "realisedPnL",
+			"periods":   past + "," + future,
+			"aggregate": aggregate,
+			"include":   "all",
+			"format":    "integer",
+		})
+		cfg.Algorithm = "ByPeriodAggregate"
+		svc := leaderboard.NewLeaderboardService(cfg)
+		svc.SetBaselineStore(baselines)
+		svc.Start()
+		svc.Stop()
+
+		payload, err := svc.JsonLeaderboard("", 0, 0, false, leaderboard.Sort{})
+		require.NoError(t, err)
+		var board leaderboard.Leaderboard
+		require.NoError(t, json.Unmarshal(payload, &board))
+		require.Len(t, board.Participants, 3, aggregate)
+		for _, p := range board.Participants {
+			require.Equal(t, []string{expected[p.PublicKey]}, p.Data, aggregate+" "+p.PublicKey)
+		}
+	}
+}
+
+func TestPeriodAggregateMidCompetition(t *testing.T) {
+	format := "2006-01-02T15:04:05Z"
+	first := time.Now().Add(-2 * time.Hour).UTC()
+	second := time.Now().Add(-time.Hour).UTC()
+	periods := first.Format(format) + "," + second.Format(format) + "," +
+		time.Now().Add(time.Hour).UTC().Format(format) + "," + time.Now().Add(2*time.Hour).UTC().Format(format)
+
+	cases := map[string]map[string]string{
+		// p1 is missing from the first boundary and keeps 3000 at the second: [0, 3000, 5000].
+		// p2 is missing from the second boundary, which keeps its first: [2000, 0, 7000].
+		// The two periods that have not started are not aggregated.
+		"median": {"p1": "3000", "p2": "2000", "p3": "0"},
+		"min":    {"p1": "0", "p2": "0", "p3": "0"},
+	}
+	for aggregate, expected := range cases {
+		baselines := leaderboard.NewFileBaselineStore(t.TempDir())
+		for name, participants := range map[string][]leaderboard.Participant{
+			"period-1": {{PublicKey: "p2", Score: "2000"}},
+			"period-2": {{PublicKey: "p1", Score: "3000"}},
+		} {
+			require.NoError(t, baselines.SaveBaseline(leaderboard.Baseline{
+				Competition: "default", Name: name, Participants: participants,
+			}))
+		}
+
+		cfg := newPipelineTestConfig(t, map[string]string{
+			"metric":    "realisedPnL",
+			"periods":   periods,
+			"aggregate": aggregate,
+			"include":   "all",
+			"format":    "integer",
+		})
+		cfg.Algorithm = "ByPeriodAggregate"
+		svc := leaderboard.NewLeaderboardService(cfg)
+		svc.SetBaselineStore(baselines)
+		svc.Start()
+		svc.Stop()
+
+		board := currentBoard(t, svc)
+		scores := map[string]string{}
+		for _, p := range board.Participants {
+			scores[p.PublicKey] = p.Data[0]
+		}
+		require.Equal(t, expected, scores, aggregate)
+	}
+}
+
+func TestPeriodAggregateCapturesBoundary(t *testing.T) {
+	past := time.Now().Add(-time.Hour).UTC().Format("2006-01-02T15:04:05Z")
+	baselines := leaderboard.NewFileBaselineStore(t.TempDir())
+
+	cfg := newPipelineTestConfig(t, map[string]string{
+		"metric":    "realisedPnL",
+		"periods":   past,
+		"aggregate": "sum",
+	})
+	cfg.Algorithm = "ByPeriodAggregate"
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.SetBaselineStore(baselines)
+	svc.Start()
+	svc.Stop()
+
+	b, err := baselines.LoadBaseline("default", "period-1")
+	require.NoError(t, err)
+	require.NotNil(t, b)
+	scores, err := b.Scores()
+	require.NoError(t, err)
+	require.Equal(t, "9000", scores["p2"].String())
+	require.Equal(t, "8000", scores["p1"].String())
+}
+
+func TestPeriodAggregateCapturesBoundaryFromSnapshot(t *testing.T) {
+	boundary := time.Now().Add(-time.Hour).UTC()
+	baselines := leaderboard.NewFileBaselineStore(t.TempDir())
+	snapshots := &memorySnapshotStore{snapshots: []leaderboard.Snapshot{{
+		Competition: "default",
+		Sequence:    1,
+		Timestamp:   boundary.Add(-time.Minute),
+		Status:      "active",
+		Cumulative:  map[string]string{"p1": "1000", "p2": "2000"},
+	}}}
+
+	cfg := newPipelineTestConfig(t, map[string]string{
+		"metric":    "realisedPnL",
+		"periods":   boundary.Format("2006-01-02T15:04:05Z"),
+		"aggregate": "sum",
+	})
+	cfg.Algorithm = "ByPeriodAggregate"
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.SetBaselineStore(baselines)
+	svc.SetSnapshotStore(snapshots)
+	svc.Start()
+	svc.Stop()
+
+	b, err := baselines.LoadBaseline("default", "period-1")
+	require.NoError(t, err)
+	require.NotNil(t, b)
+	scores, err := b.Scores()
+	require.NoError(t, err)
+	require.Equal(t, "1000", scores["p1"].String())
+	require.Equal(t, "2000", scores["p2"].String())
+
+	latest, err := snapshots.LatestSnapshot("default")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"p1": "8000", "p2": "9000", "p3": "0"}, latest.Cumulative)
+}
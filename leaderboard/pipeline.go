@@ -0,0 +1,681 @@
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/vegaprotocol/topgun-service/verifier"
+)
+
+// The pipeline algorithm builds a leaderboard from stages described in algorithmConfig:
+//
+//	source:        where party data comes from (parties)
+//	window:        time-window filter for timestamped data (competition, all, or <start>/<end>)
+//	assets:        comma-separated asset IDs to count (default: vegaAssets)
+//	markets:       comma-separated market IDs to count (default: marketIDs)
+//	metric:        terms joined with + and -, e.g. "realisedPnL + unrealisedPnL - transfers"
+//	include:       which scored parties to keep (nonZero, positive, all)
+//	ranker:        sort order of the score (desc, asc)
+//	format:        how the score is shown (decimal, integer, percent, label:<text>)
+//	precision:     digits after the decimal point for the decimal and percent formats
+//	               (default: decimals of the first asset)
+//
+// Amounts are converted to whole units using the decimals of their asset, PnL uses the first asset.
+const pipelineAlgorithmName = "ByPipeline"
+
+func init() {
+	RegisterAlgorithm(&pipelineAlgorithm{})
+}
+
+// ConfigValidator is implemented by algorithms that can check their algorithmConfig
+// in more depth than the presence of required keys.
+type ConfigValidator interface {
+	ValidateConfig(algorithmConfig map[string]string) error
+}
+
+type pipelineAlgorithm struct{}
+
+func (a *pipelineAlgorithm) Name() string             { return pipelineAlgorithmName }
+func (a *pipelineAlgorithm) RequiredConfig() []string { return []string{"metric"} }
+
+// MetricNames returns the score, typed by the formatter stage.
+func (a *pipelineAlgorithm) MetricNames() []string { return []string{"score"} }
+
+func (a *pipelineAlgorithm) ValidateConfig(algorithmConfig map[string]string) error {
+	_, err := parsePipeline(algorithmConfig)
+	return err
+}
+
+func (a *pipelineAlgorithm) Score(s *Service, socials map[string]verifier.Social) ([]Participant, error) {
+	p, err := parsePipeline(s.cfg.AlgorithmConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pipeline: %w", err)
+	}
+	return p.run(s, socials)
+}
+
+// pipelineScope holds the filters applied to party data before terms are summed.
+type pipelineScope struct {
+	assets  []string
+	markets []string
+	from    time.Time
+	to      time.Time
+
+	// decimals holds the details of every asset in assets, settlement is the first one
+	decimals   map[string]Asset
+	settlement Asset
+}
+
+func (sc *pipelineScope) inWindow(t time.Time) bool {
+	return (sc.from.IsZero() || t.After(sc.from)) && (sc.to.IsZero() || t.Before(sc.to))
+}
+
+func (sc *pipelineScope) hasAsset(id string) bool {
+	return hasString(sc.assets, id)
+}
+
+func (sc *pipelineScope) hasMarket(id string) bool {
+	return hasString(sc.markets, id)
+}
+
+// amount converts an amount of an asset in scope to whole units.
+func (sc *pipelineScope) amount(raw string, assetID string) (decimal.Decimal, error) {
+	return assetAmount(raw, sc.decimals[assetID])
+}
+
+// pipelineTerm is a named value that can be calculated for a party.
+type pipelineTerm struct {
+	// connection is the name of the party connection the term needs, see partyConnections.
+	connection string
+	value      func(p *Party, sc *pipelineScope) (decimal.Decimal, error)
+}
+
+var pipelineTerms = map[string]pipelineTerm{
+	"realisedPnL": {connection: "positions", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		total := decimal.Zero
+		for _, e := range p.PositionsConnection.Edges {
+			if sc.hasMarket(e.Position.Market.ID) {
+				value, _, _, err := e.Position.amounts(sc.settlement)
+				if err != nil {
+					return decimal.Zero, err
+				}
+				total = total.Add(value)
+			}
+		}
+		return total, nil
+	}},
+	"unrealisedPnL": {connection: "positions", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		total := decimal.Zero
+		for _, e := range p.PositionsConnection.Edges {
+			if sc.hasMarket(e.Position.Market.ID) {
+				_, value, _, err := e.Position.amounts(sc.settlement)
+				if err != nil {
+					return decimal.Zero, err
+				}
+				total = total.Add(value)
+			}
+		}
+		return total, nil
+	}},
+	"openVolume": {connection: "positions", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		total := decimal.Zero
+		for _, e := range p.PositionsConnection.Edges {
+			if sc.hasMarket(e.Position.Market.ID) {
+				_, _, value, err := e.Position.amounts(sc.settlement)
+				if err != nil {
+					return decimal.Zero, err
+				}
+				total = total.Add(value)
+			}
+		}
+		return total, nil
+	}},
+	"deposits": {connection: "deposits", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		total := decimal.Zero
+		for _, e := range p.DepositsConnection.Edges {
+			d := e.Deposit
+			if sc.hasAsset(d.Asset.Id) && d.Status == "STATUS_FINALIZED" && sc.inWindow(d.CreatedAt) {
+				amount, err := sc.amount(d.Amount, d.Asset.Id)
+				if err != nil {
+					return decimal.Zero, err
+				}
+				total = total.Add(amount)
+			}
+		}
+		return total, nil
+	}},
+	"depositCount": {connection: "deposits", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		count := int64(0)
+		for _, e := range p.DepositsConnection.Edges {
+			d := e.Deposit
+			if sc.hasAsset(d.Asset.Id) && d.Status == "STATUS_FINALIZED" && sc.inWindow(d.CreatedAt) {
+				count++
+			}
+		}
+		return decimal.NewFromInt(count), nil
+	}},
+	"withdrawals": {connection: "withdrawals", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		total := decimal.Zero
+		for _, e := range p.WithdrawalsConnection.Edges {
+			w := e.Withdrawal
+			if sc.hasAsset(w.Asset.Id) && w.Status == "STATUS_FINALIZED" && sc.inWindow(w.CreatedAt) {
+				amount, err := sc.amount(w.Amount, w.Asset.Id)
+				if err != nil {
+					return decimal.Zero, err
+				}
+				total = total.Add(amount)
+			}
+		}
+		return total, nil
+	}},
+	"withdrawalCount": {connection: "withdrawals", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		count := int64(0)
+		for _, e := range p.WithdrawalsConnection.Edges {
+			w := e.Withdrawal
+			if sc.hasAsset(w.Asset.Id) && w.Status == "STATUS_FINALIZED" && sc.inWindow(w.CreatedAt) {
+				count++
+			}
+		}
+		return decimal.NewFromInt(count), nil
+	}},
+	"transfers": {connection: "transfers", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		total := decimal.Zero
+		for _, e := range p.TransfersConnection.Edges {
+			t := e.Transfer
+			if sc.hasAsset(t.Asset.Id) && sc.inWindow(t.Timestamp) {
+				amount, err := sc.amount(t.Amount, t.Asset.Id)
+				if err != nil {
+					return decimal.Zero, err
+				}
+				total = total.Add(amount)
+			}
+		}
+		return total, nil
+	}},
+	"transferCount": {connection: "transfers", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		count := int64(0)
+		for _, e := range p.TransfersConnection.Edges {
+			t := e.Transfer
+			if sc.hasAsset(t.Asset.Id) && sc.inWindow(t.Timestamp) {
+				count++
+			}
+		}
+		return decimal.NewFromInt(count), nil
+	}},
+	"rewards": {connection: "rewards", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		total := decimal.Zero
+		for _, e := range p.RewardsConnection.Edges {
+			r := e.Reward
+			if sc.hasAsset(r.Asset.Id) && sc.inWindow(r.ReceivedAt) {
+				amount, err := sc.amount(r.Amount, r.Asset.Id)
+				if err != nil {
+					return decimal.Zero, err
+				}
+				total = total.Add(amount)
+			}
+		}
+		return total, nil
+	}},
+	"generalBalance": {connection: "accounts", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		return pipelineBalance(p, sc, "ACCOUNT_TYPE_GENERAL")
+	}},
+	"marginBalance": {connection: "accounts", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		return pipelineBalance(p, sc, "ACCOUNT_TYPE_MARGIN")
+	}},
+	"votes": {connection: "votes", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		count := int64(0)
+		for _, e := range p.VotesConnection.Edges {
+			if sc.inWindow(e.Vote.Datetime) {
+				count++
+			}
+		}
+		return decimal.NewFromInt(count), nil
+	}},
+	"lpCommitments": {connection: "liquidityProvisions", value: func(p *Party, sc *pipelineScope) (decimal.Decimal, error) {
+		count := int64(0)
+		for _, e := range p.LPsConnection.Edges {
+			if sc.hasMarket(e.LP.Market.ID) {
+				count++
+			}
+		}
+		return decimal.NewFromInt(count), nil
+	}},
+}
+
+// pipelineActions return the time of a party's first qualifying action in a connection, used
+// to break ties. Connections without timestamps, e.g. positions, are not listed.
+var pipelineActions = map[string]func(p *Party, sc *pipelineScope) time.Time{
+	"deposits": func(p *Party, sc *pipelineScope) time.Time {
+		first := time.Time{}
+		for _, e := range p.DepositsConnection.Edges {
+			d := e.Deposit
+			if sc.hasAsset(d.Asset.Id) && d.Status == "STATUS_FINALIZED" && sc.inWindow(d.CreatedAt) {
+				first = earliest(first, d.CreatedAt)
+			}
+		}
+		return first
+	},
+	"withdrawals": func(p *Party, sc *pipelineScope) time.Time {
+		first := time.Time{}
+		for _, e := range p.WithdrawalsConnection.Edges {
+			w := e.Withdrawal
+			if sc.hasAsset(w.Asset.Id) && w.Status == "STATUS_FINALIZED" && sc.inWindow(w.CreatedAt) {
+				first = earliest(first, w.CreatedAt)
+			}
+		}
+		return first
+	},
+	"transfers": func(p *Party, sc *pipelineScope) time.Time {
+		first := time.Time{}
+		for _, e := range p.TransfersConnection.Edges {
+			t := e.Transfer
+			if sc.hasAsset(t.Asset.Id) && sc.inWindow(t.Timestamp) {
+				first = earliest(first, t.Timestamp)
+			}
+		}
+		return first
+	},
+	"rewards": func(p *Party, sc *pipelineScope) time.Time {
+		first := time.Time{}
+		for _, e := range p.RewardsConnection.Edges {
+			r := e.Reward
+			if sc.hasAsset(r.Asset.Id) && sc.inWindow(r.ReceivedAt) {
+				first = earliest(first, r.ReceivedAt)
+			}
+		}
+		return first
+	},
+	"votes": func(p *Party, sc *pipelineScope) time.Time {
+		first := time.Time{}
+		for _, e := range p.VotesConnection.Edges {
+			if sc.inWindow(e.Vote.Datetime) {
+				first = earliest(first, e.Vote.Datetime)
+			}
+		}
+		return first
+	},
+	"liquidityProvisions": func(p *Party, sc *pipelineScope) time.Time {
+		first := time.Time{}
+		for _, e := range p.LPsConnection.Edges {
+			if sc.hasMarket(e.LP.Market.ID) {
+				first = earliest(first, e.LP.CreatedAt)
+			}
+		}
+		return first
+	},
+}
+
+func pipelineBalance(p *Party, sc *pipelineScope, accountType string) (decimal.Decimal, error) {
+	total := decimal.Zero
+	for _, e := range p.AccountsConnection.Edges {
+		if e.Account.Type == accountType && sc.hasAsset(e.Account.Asset.Id) {
+			amount, err := sc.amount(e.Account.Balance, e.Account.Asset.Id)
+			if err != nil {
+				return decimal.Zero, err
+			}
+			total = total.Add(amount)
+		}
+	}
+	return total, nil
+}
+
+// signedTerm is one term of a metric expression.
+type signedTerm struct {
+	name     string
+	negative bool
+}
+
+// pipeline is a parsed, ready to run set of stages.
+type pipeline struct {
+	source    string
+	window    string
+	assets    []string
+	markets   []string
+	metric    []signedTerm
+	include   string
+	ranker    string
+	format    string
+	precision int
+}
+
+func parsePipeline(cfg map[string]string) (*pipeline, error) {
+	p := &pipeline{
+		source:    pipelineConfigOr(cfg, "source", "parties"),
+		window:    pipelineConfigOr(cfg, "window", "competition"),
+		assets:    splitList(cfg["assets"]),
+		markets:   splitList(cfg["markets"]),
+		include:   pipelineConfigOr(cfg, "include", "nonZero"),
+		ranker:    pipelineConfigOr(cfg, "ranker", "desc"),
+		format:    pipelineConfigOr(cfg, "format", "decimal"),
+		precision: -1,
+	}
+
+	if p.source != "parties" {
+		return nil, fmt.Errorf("invalid pipeline source: %s", p.source)
+	}
+	if p.window != "competition" && p.window != "all" {
+		if _, _, err := parseWindow(p.window); err != nil {
+			return nil, err
+		}
+	}
+
+	metric, err := parseMetric(cfg["metric"])
+	if err != nil {
+		return nil, err
+	}
+	p.metric = metric
+
+	switch p.include {
+	case "nonZero", "positive", "all":
+	default:
+		return nil, fmt.Errorf("invalid pipeline include: %s", p.include)
+	}
+	switch p.ranker {
+	case "desc", "asc":
+	default:
+		return nil, fmt.Errorf("invalid pipeline ranker: %s", p.ranker)
+	}
+	switch {
+	case p.format == "decimal", p.format == "integer", p.format == "percent":
+	case strings.HasPrefix(p.format, "label:"):
+	default:
+		return nil, fmt.Errorf("invalid pipeline format: %s", p.format)
+	}
+
+	if v, found := cfg["precision"]; found {
+		precision, err := strconv.Atoi(v)
+		if err != nil || precision < 0 {
+			return nil, fmt.Errorf("invalid pipeline precision: %s", v)
+		}
+		p.precision = precision
+	}
+	return p, nil
+}
+
+func pipelineConfigOr(cfg map[string]string, key, def string) string {
+	if v, found := cfg[key]; found && v != "" {
+		return v
+	}
+	return def
+}
+
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parseWindow parses a time window of the form <start>/<end>, e.g. 2023-03-17T10:00:00Z/2023-03-18T10:00:00Z.
+func parseWindow(window string) (time.Time, time.Time, error) {
+	parts := strings.Split(window, "/")
+	if len(parts) != 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid pipeline window: %s", window)
+	}
+	from, err := parseTime(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid pipeline window start: %w", err)
+	}
+	to, err := parseTime(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid pipeline window end: %w", err)
+	}
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid pipeline window: end is not after start")
+	}
+	return from, to, nil
+}
+
+// parseMetric parses an expression of terms joined by + and -, e.g. "realisedPnL - transfers".
+func parseMetric(expr string) ([]signedTerm, error) {
+	expr = strings.NewReplacer("+", " + ", "-", " - ").Replace(expr)
+	fields := strings.Fields(expr)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing pipeline metric")
+	}
+
+	terms := []signedTerm{}
+	negative := false
+	expectTerm := true
+	for _, f := range fields {
+		switch {
+		case f == "+" || f == "-":
+			if !expectTerm {
+				negative = false
+				expectTerm = true
+			}
+			if f == "-" {
+				negative = !negative
+			}
+		case expectTerm:
+			if _, found := pipelineTerms[f]; !found {
+				return nil, fmt.Errorf("unknown pipeline metric term: %s", f)
+			}
+			terms = append(terms, signedTerm{name: f, negative: negative})
+			expectTerm = false
+		default:
+			return nil, fmt.Errorf("invalid pipeline metric, missing operator before: %s", f)
+		}
+	}
+	if expectTerm {
+		return nil, fmt.Errorf("invalid pipeline metric, trailing operator: %s", expr)
+	}
+	return terms, nil
+}
+
+// connections returns the names of the connections needed by the metric terms, sorted.
+func (p *pipeline) connections() []string {
+	needed := map[string]bool{}
+	for _, t := range p.metric {
+		needed[pipelineTerms[t.name].connection] = true
+	}
+	names := make([]string, 0, len(needed))
+	for name := range needed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *pipeline) scope(s *Service) (*pipelineScope, error) {
+	sc := &pipelineScope{
+		assets:   p.assets,
+		markets:  p.markets,
+		decimals: map[string]Asset{},
+	}
+	if len(sc.assets) == 0 {
+		sc.assets = s.cfg.VegaAssets
+	}
+	if len(sc.markets) == 0 {
+		sc.markets = s.cfg.MarketIDs
+	}
+	for _, id := range sc.assets {
+		asset, err := s.asset(id)
+		if err != nil {
+			return nil, err
+		}
+		sc.decimals[id] = asset
+	}
+	if len(sc.assets) > 0 {
+		sc.settlement = sc.decimals[sc.assets[0]]
+	}
+	switch p.window {
+	case "all":
+	case "competition":
+		sc.from, sc.to = s.cfg.StartTime, s.cfg.EndTime
+	default:
+		from, to, err := parseWindow(p.window)
+		if err != nil {
+			return nil, err
+		}
+		sc.from, sc.to = from, to
+	}
+	return sc, nil
+}
+
+// fetch is the data source stage.
+func (p *pipeline) fetch(ctx context.Context, s *Service, socials map[string]verifier.Social) ([]PartiesEdge, error) {
+	partyEdges, err := s.fetchParties(ctx, socialKeys(socials), p.connections()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of parties: %w", err)
+	}
+	return partyEdges, nil
+}
+
+// score is the metric stage.
+func (p *pipeline) score(party *Party, sc *pipelineScope) (decimal.Decimal, error) {
+	total := decimal.Zero
+	for _, t := range p.metric {
+		value, err := pipelineTerms[t.name].value(party, sc)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("%s of %s: %w", t.name, party.ID, err)
+		}
+		if t.negative {
+			total = total.Sub(value)
+		} else {
+			total = total.Add(value)
+		}
+	}
+	return total, nil
+}
+
+// breakdown lists the data and terms a party's score was calculated from.
+func (p *pipeline) breakdown(party *Party, sc *pipelineScope, score decimal.Decimal) (*ScoreBreakdown, error) {
+	b := newScoreBreakdown(party, sc)
+	types := []string{}
+	for _, t := range p.metric {
+		switch t.name {
+		case "generalBalance":
+			types = append(types, "ACCOUNT_TYPE_GENERAL")
+		case "marginBalance":
+			types = append(types, "ACCOUNT_TYPE_MARGIN")
+		}
+	}
+	countAccounts(b.Accounts, sc.assets, types...)
+	for _, t := range p.metric {
+		value, err := pipelineTerms[t.name].value(party, sc)
+		if err != nil {
+			return nil, fmt.Errorf("%s of %s: %w", t.name, party.ID, err)
+		}
+		b.addTerm(t.name, value, t.negative)
+	}
+	b.Score = score.String()
+	return b, nil
+}
+
+// firstAction returns the time of the party's first action counted by the metric, or the
+// zero time if none of the metric's connections are timestamped.
+func (p *pipeline) firstAction(party *Party, sc *pipelineScope) time.Time {
+	first := time.Time{}
+	for _, name := range p.connections() {
+		if action, found := pipelineActions[name]; found {
+			first = earliest(first, action(party, sc))
+		}
+	}
+	return first
+}
+
+// included is the participant filter stage.
+func (p *pipeline) included(score decimal.Decimal) bool {
+	switch p.include {
+	case "all":
+		return true
+	case "positive":
+		return score.IsPositive()
+	default:
+		return !score.IsZero()
+	}
+}
+
+// rank is the ranker stage.
+func (p *pipeline) rank(participants []Participant) {
+	if p.ranker == "asc" {
+		sortAscending(participants)
+	} else {
+		sortDescending(participants)
+	}
+}
+
+// scoreMetric returns the score as a typed metric, with the precision of the formatter stage.
+func (p *pipeline) scoreMetric(score decimal.Decimal, sc *pipelineScope) Metric {
+	precision := int32(p.precision)
+	if precision < 0 {
+		precision = int32(sc.settlement.Decimals)
+	}
+	switch p.format {
+	case "integer":
+		return Metric{Name: "score", Type: MetricInteger, Value: score.StringFixed(0)}
+	case "percent":
+		return percentMetric("score", score.Shift(2), precision)
+	default:
+		// Labels replace the score for display only, the metric keeps it
+		return decimalMetric("score", score, precision)
+	}
+}
+
+// formatValue is the formatter stage.
+func (p *pipeline) formatValue(score decimal.Decimal, sc *pipelineScope) string {
+	precision := int32(p.precision)
+	if precision < 0 {
+		precision = int32(sc.settlement.Decimals)
+	}
+	switch {
+	case p.format == "integer":
+		return score.StringFixed(0)
+	case p.format == "percent":
+		return score.Shift(2).StringFixed(precision) + "%"
+	case strings.HasPrefix(p.format, "label:"):
+		return strings.TrimPrefix(p.format, "label:")
+	default:
+		return score.StringFixed(precision)
+	}
+}
+
+func (p *pipeline) run(s *Service, socials map[string]verifier.Social) ([]Participant, error) {
+	sc, err := p.scope(s)
+	if err != nil {
+		return nil, err
+	}
+	partyEdges, err := p.fetch(s.ctx, s, socials)
+	if err != nil {
+		return nil, err
+	}
+
+	sParties := socialParties(socials, partyEdges)
+	participants := []Participant{}
+	for i := range sParties {
+		party := &sParties[i]
+		score, err := p.score(party, sc)
+		if err != nil {
+			return nil, err
+		}
+		if !p.included(score) {
+			continue
+		}
+		breakdown, err := p.breakdown(party, sc, score)
+		if err != nil {
+			return nil, err
+		}
+		t := time.Now().UTC()
+		participants = append(participants, Participant{
+			PublicKey:   party.ID,
+			Data:        []string{p.formatValue(score, sc)},
+			Metrics:     Metrics{p.scoreMetric(score, sc)},
+			sortNum:     score,
+			firstAction: p.firstAction(party, sc),
+			breakdown:   breakdown,
+			CreatedAt:   t,
+			UpdatedAt:   t,
+		})
+	}
+	p.rank(participants)
+	return participants, nil
+}
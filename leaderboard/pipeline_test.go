@@ -0,0 +1,223 @@
+package leaderboard_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+	"github.com/vegaprotocol/topgun-service/verifier"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testPartiesResponse = `{"data": {"partiesConnection": {
+	"edges": [
+		{"node": {"id": "p1",
+			"positionsConnection": {"edges": [
+				{"node": {"market": {"id": "m1"}, "openVolume": "1", "realisedPNL": "5000", "unrealisedPNL": "0"}},
+				{"node": {"market": {"id": "m2"}, "openVolume": "1", "realisedPNL": "3000", "unrealisedPNL": "0"}},
+				{"node": {"market": {"id": "other"}, "openVolume": "1", "realisedPNL": "99999", "unrealisedPNL": "0"}}
+			]},
+			"transfersConnection": {"edges": [
+				{"node": {"id": "t1", "amount": "1000", "timestamp": "2023-03-17T12:00:00Z", "asset": {"id": "a1"}}},
+				{"node": {"id": "t2", "amount": "7000", "timestamp": "2020-01-01T00:00:00Z", "asset": {"id": "a1"}}}
+			]}
+		}},
+		{"node": {"id": "p2",
+			"positionsConnection": {"edges": [
+				{"node": {"market": {"id": "m1"}, "openVolume": "1", "realisedPNL": "9000", "unrealisedPNL": "0"}}
+			]},
+			"transfersConnection": {"edges": [
+				{"node": {"id": "t3", "amount": "0", "timestamp": "2023-03-17T11:00:00Z", "asset": {"id": "a1"}}}
+			]}
+		}},
+		{"node": {"id": "unregistered",
+			"positionsConnection": {"edges": [
+				{"node": {"market": {"id": "m1"}, "openVolume": "1", "realisedPNL": "100000", "unrealisedPNL": "0"}}
+			]}
+		}}
+	],
+	"pageInfo": {"hasNextPage": false}
+}}}`
+
+const testAssetsResponse = `{"data": {"assetsConnection": {"edges": [
+	{"node": {"id": "a1", "name": "Asset One", "symbol": "A1", "decimals": 0}},
+	{"node": {"id": "a2", "name": "Asset Two", "symbol": "A2", "decimals": 3}}
+]}}}`
+
+const testSocialsResponse = `[
+	{"party_id": "p1", "twitter_handle": "one", "twitter_user_id": 1, "created": 1679047200},
+	{"party_id": "p2", "twitter_handle": "two", "twitter_user_id": 2, "created": 1679050800},
+	{"party_id": "p3", "twitter_handle": "three", "twitter_user_id": 3, "created": 1679043600}
+]`
+
+func newTestAPI(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/socials", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testSocialsResponse))
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var req testGraphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(req.Query, "assetsConnection"):
+			w.Write([]byte(testAssetsResponse))
+		case strings.Contains(req.Query, "p0: party("):
+			writeTestPartyBatch(t, w, testPartiesResponse, req.Variables)
+		default:
+			w.Write([]byte(testPartiesResponse))
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type testGraphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// writeTestPartyBatch answers a batch of aliased party queries with the matching parties
+// from a partiesConnection response, or null for parties that are not in it.
+func writeTestPartyBatch(t *testing.T, w http.ResponseWriter, parties string, vars map[string]interface{}) {
+	var all struct {
+		Data struct {
+			PartiesConnection struct {
+				Edges []struct {
+					Node json.RawMessage `json:"node"`
+				} `json:"edges"`
+			} `json:"partiesConnection"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(parties), &all))
+	byID := map[string]json.RawMessage{}
+	for _, edge := range all.Data.PartiesConnection.Edges {
+		var party struct {
+			ID string `json:"id"`
+		}
+		require.NoError(t, json.Unmarshal(edge.Node, &party))
+		byID[party.ID] = edge.Node
+	}
+
+	batch := map[string]json.RawMessage{}
+	for alias, id := range vars {
+		if node, found := byID[id.(string)]; found {
+			batch[alias] = node
+		} else {
+			batch[alias] = json.RawMessage("null")
+		}
+	}
+	require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{"data": batch}))
+}
+
+func newPipelineTestConfig(t *testing.T, algorithmConfig map[string]string) config.Config {
+	srv := newTestAPI(t)
+	base, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	socialURL := *base
+	socialURL.Path = "/socials"
+	gqlURL := *base
+	gqlURL.Path = "/graphql"
+
+	return config.Config{
+		Algorithm:       "ByPipeline",
+		AlgorithmConfig: algorithmConfig,
+		StartTime:       time.Date(2023, 3, 17, 10, 0, 0, 0, time.UTC),
+		EndTime:         time.Now().Add(time.Hour),
+		VegaAssets:      []string{"a1"},
+		MarketIDs:       []string{"m1", "m2"},
+		VegaPoll:        time.Hour,
+		SocialURL:       &socialURL,
+		VegaGraphQLURL:  &gqlURL,
+	}
+}
+
+func TestPipelineValidation(t *testing.T) {
+	v := leaderboard.AlgorithmValidator{}
+
+	valid := []map[string]string{
+		{"metric": "realisedPnL - transfers"},
+		{"metric": "realisedPnL+unrealisedPnL-deposits", "ranker": "asc", "format": "percent", "precision": "2"},
+		{"metric": "-transfers", "window": "2023-03-17T10:00:00Z/2023-03-18T10:00:00Z"},
+		{"metric": "votes", "format": "label:Voted", "include": "positive"},
+	}
+	for _, cfg := range valid {
+		require.NoError(t, v.ValidateAlgorithm("ByPipeline", cfg), cfg)
+	}
+
+	invalid := []map[string]string{
+		{},
+		{"metric": ""},
+		{"metric": "realisedPnL -"},
+		{"metric": "realisedPnL transfers"},
+		{"metric": "luck"},
+		{"metric": "votes", "ranker": "sideways"},
+		{"metric": "votes", "source": "ether"},
+		{"metric": "votes", "format": "roman"},
+		{"metric": "votes", "window": "yesterday"},
+		{"metric": "votes", "window": "2023-03-18T10:00:00Z/2023-03-17T10:00:00Z"},
+	}
+	for _, cfg := range invalid {
+		require.Error(t, v.ValidateAlgorithm("ByPipeline", cfg), cfg)
+	}
+}
+
+func TestPipelineScoresRegisteredParties(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{
+		"metric":    "realisedPnL - transfers",
+		"format":    "decimal",
+		"precision": "1",
+	})
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	payload, err := svc.JsonLeaderboard("", 0, 0, false, leaderboard.Sort{})
+	require.NoError(t, err)
+
+	var board leaderboard.Leaderboard
+	require.NoError(t, json.Unmarshal(payload, &board))
+	require.Equal(t, "active", board.Status)
+	require.Len(t, board.Participants, 2)
+
+	// p2: 9000 on m1. p1: 5000 + 3000 on m1/m2, minus the one transfer inside the window.
+	require.Equal(t, "p2", board.Participants[0].PublicKey)
+	require.Equal(t, []string{"9000.0"}, board.Participants[0].Data)
+	require.Equal(t, 1, board.Participants[0].Position)
+	require.Equal(t, "p1", board.Participants[1].PublicKey)
+	require.Equal(t, []string{"7000.0"}, board.Participants[1].Data)
+	require.Equal(t, 2, board.Participants[1].Position)
+	require.Equal(t, verifier.Identity{Provider: "twitter", Handle: "two", UserID: "2"}, board.Participants[0].Identity)
+
+	csv, err := svc.CsvLeaderboard("", 0, 0, false, leaderboard.Sort{})
+	require.NoError(t, err)
+	require.Contains(t, string(csv), "position,provider,handle,provider_user_id,")
+	require.Contains(t, string(csv), "1,twitter,two,2,")
+}
+
+func TestPipelineUsesAssetDecimals(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	cfg.VegaAssets = []string{"a2"}
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	payload, err := svc.JsonLeaderboard("", 0, 0, false, leaderboard.Sort{})
+	require.NoError(t, err)
+
+	var board leaderboard.Leaderboard
+	require.NoError(t, json.Unmarshal(payload, &board))
+	require.Len(t, board.Participants, 2)
+
+	// a2 has 3 decimals, which also sets the default precision
+	require.Equal(t, []string{"9.000"}, board.Participants[0].Data)
+	require.Equal(t, []string{"8.000"}, board.Participants[1].Data)
+}
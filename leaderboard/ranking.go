@@ -0,0 +1,67 @@
+package leaderboard
+
+import (
+	"sort"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/verifier"
+)
+
+// breakTies orders participants with the same score by the competition's tie-break policy.
+// Algorithms sort by score only, and not stably, so without this tied parties would swap
+// places between polls. Participants must already be sorted by score, ascending or descending.
+func (s *Service) breakTies(p []Participant, socials map[string]verifier.Social) {
+	less := tieBreaker(s.cfg.TieBreak, socials)
+	start := 0
+	for i := 1; i <= len(p); i++ {
+		if i < len(p) && p[i].sortNum.Equal(p[start].sortNum) {
+			continue
+		}
+		if i-start > 1 {
+			tied := p[start:i]
+			sort.SliceStable(tied, func(a, b int) bool {
+				return less(&tied[a], &tied[b])
+			})
+		}
+		start = i
+	}
+}
+
+// tieBreaker returns the ordering of tied participants for a tie-break policy. Every policy
+// falls back to the public key, so the order is always deterministic.
+func tieBreaker(policy string, socials map[string]verifier.Social) func(a, b *Participant) bool {
+	byPublicKey := func(a, b *Participant) bool {
+		return a.PublicKey < b.PublicKey
+	}
+	switch policy {
+	case config.TieBreakRegistration:
+		return func(a, b *Participant) bool {
+			ra, rb := socials[a.PublicKey].CreatedAt, socials[b.PublicKey].CreatedAt
+			if ra != rb {
+				// Unknown registration times sort last
+				return ra != 0 && (rb == 0 || ra < rb)
+			}
+			return byPublicKey(a, b)
+		}
+	case config.TieBreakFirstAction:
+		return func(a, b *Participant) bool {
+			fa, fb := a.firstAction, b.firstAction
+			if !fa.Equal(fb) {
+				// Parties without a recorded action sort last
+				return !fa.IsZero() && (fb.IsZero() || fa.Before(fb))
+			}
+			return byPublicKey(a, b)
+		}
+	default:
+		return byPublicKey
+	}
+}
+
+// earliest returns the earlier of two times, ignoring zero times.
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
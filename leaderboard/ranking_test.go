@@ -0,0 +1,91 @@
+package leaderboard_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func rankedBoard(t *testing.T, cfg config.Config) leaderboard.Leaderboard {
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	payload, err := svc.JsonLeaderboard("", 0, 0, false, leaderboard.Sort{})
+	require.NoError(t, err)
+	var board leaderboard.Leaderboard
+	require.NoError(t, json.Unmarshal(payload, &board))
+	return board
+}
+
+func publicKeys(board leaderboard.Leaderboard) []string {
+	keys := []string{}
+	for _, p := range board.Participants {
+		keys = append(keys, p.PublicKey)
+	}
+	return keys
+}
+
+func positions(board leaderboard.Leaderboard) []int {
+	ps := []int{}
+	for _, p := range board.Participants {
+		ps = append(ps, p.Position)
+	}
+	return ps
+}
+
+func TestTieBreakPolicies(t *testing.T) {
+	// p1 and p2 both have one transfer in the window, p3 has none
+	algorithmConfig := map[string]string{"metric": "transferCount", "include": "all"}
+
+	cfg := newPipelineTestConfig(t, algorithmConfig)
+	board := rankedBoard(t, cfg)
+	require.Equal(t, []string{"p1", "p2", "p3"}, publicKeys(board))
+	require.Equal(t, []int{1, 2, 3}, positions(board))
+
+	// p2 transferred first
+	cfg = newPipelineTestConfig(t, algorithmConfig)
+	cfg.TieBreak = config.TieBreakFirstAction
+	require.Equal(t, []string{"p2", "p1", "p3"}, publicKeys(rankedBoard(t, cfg)))
+
+	// p1 registered before p2
+	cfg = newPipelineTestConfig(t, algorithmConfig)
+	cfg.TieBreak = config.TieBreakRegistration
+	require.Equal(t, []string{"p1", "p2", "p3"}, publicKeys(rankedBoard(t, cfg)))
+
+	// Ascending, p3 registered first
+	algorithmConfig["ranker"] = "asc"
+	cfg = newPipelineTestConfig(t, algorithmConfig)
+	cfg.TieBreak = config.TieBreakRegistration
+	require.Equal(t, []string{"p3", "p1", "p2"}, publicKeys(rankedBoard(t, cfg)))
+}
+
+func TestSharedRanking(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "transferCount", "include": "all"})
+	cfg.Ranking = config.RankingShared
+	cfg.TieBreak = config.TieBreakFirstAction
+
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	payload, err := svc.JsonLeaderboard("", 0, 0, false, leaderboard.Sort{})
+	require.NoError(t, err)
+	var board leaderboard.Leaderboard
+	require.NoError(t, json.Unmarshal(payload, &board))
+	require.Equal(t, []string{"p2", "p1", "p3"}, publicKeys(board))
+	require.Equal(t, []int{1, 1, 3}, positions(board))
+
+	payload, err = svc.CsvLeaderboard("", 0, 0, false, leaderboard.Sort{})
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(payload)), "\n")
+	require.Len(t, lines, 4)
+	require.True(t, strings.HasPrefix(lines[1], "1,"), lines[1])
+	require.True(t, strings.HasPrefix(lines[2], "1,"), lines[2])
+	require.True(t, strings.HasPrefix(lines[3], "3,"), lines[3])
+}
@@ -0,0 +1,92 @@
+package leaderboard
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Reload applies the changes of a new, already checked, config that are safe to make while
+// running: description, headers, exclusions, poll interval, end time and algorithmConfig.
+// Every other change needs a restart, and is logged and ignored. Competitions that were
+// added or removed are ignored too.
+func (h *Host) Reload(cfg config.Config) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !reflect.DeepEqual(withoutReloadable(h.cfg), withoutReloadable(cfg)) {
+		log.Warn("Config changes other than to description, headers, exclusions, vegaPoll, endTime and algorithmConfig need a restart")
+	}
+	current := map[string]config.Competition{}
+	for _, comp := range h.cfg.CompetitionList() {
+		current[comp.ID] = comp
+	}
+	for _, comp := range cfg.CompetitionList() {
+		svc, found := h.byID[comp.ID]
+		if !found {
+			log.WithField("competition", comp.ID).Warn("New competitions need a restart")
+			continue
+		}
+		if !reflect.DeepEqual(competitionWithoutReloadable(current[comp.ID]), competitionWithoutReloadable(comp)) {
+			log.WithField("competition", comp.ID).Warn("Competition changes other than to description, headers, endTime and algorithmConfig need a restart")
+		}
+		svc.Reload(cfg.ForCompetition(comp))
+		delete(current, comp.ID)
+	}
+	for id := range current {
+		log.WithField("competition", id).Warn("Removed competitions keep running until a restart")
+	}
+	h.cfg = cfg
+}
+
+// Reload applies the reloadable fields of a competition config, see Host.Reload. It waits
+// for a running update to finish, and the changes apply from the next update, apart from
+// the description and headers which are served straight away.
+func (s *Service) Reload(cfg config.Config) {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pollChanged := cfg.VegaPoll != s.cfg.VegaPoll
+	s.cfg.Description = cfg.Description
+	s.cfg.Headers = cfg.Headers
+	s.cfg.VegaPoll = cfg.VegaPoll
+	s.cfg.EndTime = cfg.EndTime
+	s.cfg.AlgorithmConfig = cfg.AlgorithmConfig
+	s.cfg.Exclusions = cfg.Exclusions
+	s.cfg.TwitterBlacklist = cfg.TwitterBlacklist
+	s.exclusions = newExclusionList(s.cfg)
+
+	s.board.Description = s.cfg.Description
+	s.board.Headers = s.cfg.Headers
+	if pollChanged && s.timer != nil {
+		s.timer.Reset(s.cfg.VegaPoll)
+	}
+	log.WithField("competition", s.ID()).Info("Config reloaded")
+}
+
+// withoutReloadable returns a copy of the config with the fields applied by Reload cleared.
+// Competitions are compared one by one, see competitionWithoutReloadable.
+func withoutReloadable(cfg config.Config) config.Config {
+	cfg = cfg.ForCompetition(config.Competition{})
+	cfg.VegaPoll = 0
+	cfg.Exclusions = nil
+	cfg.TwitterBlacklist = nil
+	// Logging is reconfigured by the caller
+	cfg.LogFormat = ""
+	cfg.LogLevel = ""
+	cfg.LogMethodName = false
+	return cfg
+}
+
+func competitionWithoutReloadable(comp config.Competition) config.Competition {
+	comp.Description = ""
+	comp.Headers = nil
+	comp.EndTime = time.Time{}
+	comp.AlgorithmConfig = nil
+	return comp
+}
@@ -0,0 +1,41 @@
+package leaderboard_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadAppliesSafeChanges(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	cfg.Description = "Before"
+	host := leaderboard.NewHost(cfg)
+	host.Start()
+	defer host.Stop()
+	require.Equal(t, []string{"p2", "p1"}, publicKeys(currentBoard(t, host.Default())))
+
+	next := cfg
+	next.Description = "After"
+	next.Headers = []string{"Party", "PnL"}
+	next.AlgorithmConfig = map[string]string{"metric": "realisedPnL", "ranker": "asc"}
+	next.Exclusions = []config.Exclusion{{PublicKey: "p3", Reason: "team member"}}
+	next.VegaPoll = 10 * time.Millisecond
+	// Needs a restart, so it is ignored
+	next.Algorithm = "BySocialRegistration"
+	host.Reload(next)
+
+	summary := host.Summaries()[0]
+	require.Equal(t, "After", summary.Description)
+	require.Equal(t, "ByPipeline", summary.Algorithm)
+	require.Equal(t, []string{"Party", "PnL"}, currentBoard(t, host.Default()).Headers)
+
+	// The shorter poll interval runs the next update with the new algorithmConfig
+	require.Eventually(t, func() bool {
+		keys := publicKeys(currentBoard(t, host.Default()))
+		return len(keys) == 2 && keys[0] == "p1"
+	}, time.Second, 5*time.Millisecond)
+}
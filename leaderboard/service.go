@@ -1,21 +1,27 @@
 package leaderboard
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gocarina/gocsv"
 	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/datanode"
+	"github.com/vegaprotocol/topgun-service/datastore"
+	"github.com/vegaprotocol/topgun-service/metrics"
 	"github.com/vegaprotocol/topgun-service/pricing"
 	"github.com/vegaprotocol/topgun-service/util"
 	"github.com/vegaprotocol/topgun-service/verifier"
 
 	ppconfig "code.vegaprotocol.io/priceproxy/config"
 	ppservice "code.vegaprotocol.io/priceproxy/service"
+	"github.com/shopspring/decimal"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -31,8 +37,41 @@ type Participant struct {
 	UpdatedAt time.Time `json:"updatedAt" bson:"last_modified,omitempty"`
 	Data      []string  `json:"data" bson:"data,omitempty"`
 
+	// Metrics are the typed values the participant was scored on. Data holds the same values
+	// formatted for display, matched to the headers by index, for existing frontends.
+	Metrics Metrics `json:"metrics" bson:"metrics,omitempty"`
+
+	// Score is the value the algorithm ranked the participant by, unformatted. Baselines
+	// captured from the board are read from it.
+	Score string `json:"score,omitempty" bson:"score,omitempty"`
+
+	// Identity is the account the public key was verified with, empty for algorithms
+	// that rank public keys without a verified social
+	Identity verifier.Identity `json:"identity" bson:"identity,omitempty"`
+
+	// ExclusionReason is the reason of the exclusion matching a blacklisted participant
+	ExclusionReason string `json:"exclusionReason,omitempty" bson:"exclusion_reason,omitempty"`
+
+	// PreviousPosition is the position on the previous board, and ReferencePosition the
+	// position on the board at the reference time. They are zero for participants who were
+	// not on those boards. The changes are positive for participants who climbed.
+	PreviousPosition  int `json:"previousPosition,omitempty" bson:"previous_position,omitempty"`
+	PositionChange    int `json:"positionChange,omitempty" bson:"position_change,omitempty"`
+	ReferencePosition int `json:"referencePosition,omitempty" bson:"reference_position,omitempty"`
+	ReferenceChange   int `json:"referenceChange,omitempty" bson:"reference_change,omitempty"`
+
+	// ScoreChange is the change of the score since the previous update, empty if the
+	// previous score is unknown
+	ScoreChange string `json:"scoreChange,omitempty" bson:"score_change,omitempty"`
+
 	isBlacklisted bool
-	sortNum       float64
+	sortNum       decimal.Decimal
+
+	// firstAction is the time of the party's first qualifying action, if the algorithm records it
+	firstAction time.Time
+
+	// breakdown holds the data the score was calculated from, if the algorithm records it
+	breakdown *ScoreBreakdown
 }
 
 type Leaderboard struct {
@@ -45,6 +84,12 @@ type Leaderboard struct {
 	DefaultDisplay string   `json:"defaultDisplay"`
 	Status         string   `json:"status"`
 
+	// Freshness tells whether the participants are up to date with Vega
+	Freshness Freshness `json:"freshness"`
+
+	// ReferenceTime is the unix time of the board that reference positions are taken from
+	ReferenceTime string `json:"referenceTime,omitempty"`
+
 	// Participants is the filtered list of participants in an active incentive
 	Participants []Participant `json:"participants"`
 
@@ -53,19 +98,82 @@ type Leaderboard struct {
 	blacklisted []Participant
 }
 
+// NewDataNodeClient creates a client for the Vega data nodes in the config.
+func NewDataNodeClient(cfg config.Config) *datanode.Client {
+	urls := []url.URL{}
+	for _, u := range append([]*url.URL{cfg.VegaGraphQLURL}, cfg.VegaGraphQLURLs...) {
+		if u != nil {
+			urls = append(urls, *u)
+		}
+	}
+	return datanode.NewClient(datanode.Config{
+		URLs:    urls,
+		Timeout: cfg.VegaRequestTimeout,
+		Retries: cfg.VegaRetries,
+	})
+}
+
+// NewVerifier creates a verifier merging the socials of every source in the config. The
+// MongoDB collection is only read if a connected datastore is given.
+func NewVerifier(cfg config.Config, ds *datastore.Service) verifier.Verifier {
+	verifiers := []verifier.Verifier{}
+	if cfg.HasSocialURL() {
+		verifiers = append(verifiers, verifier.NewHTTPVerifier(*cfg.SocialURL))
+	}
+	if len(cfg.SocialFile) > 0 {
+		verifiers = append(verifiers, verifier.NewFileVerifier(cfg.SocialFile))
+	}
+	if len(cfg.SocialCollectionName) > 0 && ds != nil {
+		verifiers = append(verifiers, verifier.NewMongoVerifier(ds, cfg.MongoDatabaseName, cfg.SocialCollectionName))
+	}
+	if len(cfg.Socials) > 0 {
+		socials := make([]verifier.Social, 0, len(cfg.Socials))
+		for _, social := range cfg.Socials {
+			socials = append(socials, verifier.Social{
+				PartyID: social.PartyID,
+				Identity: verifier.Identity{
+					Provider: social.Provider,
+					Handle:   social.Handle,
+					UserID:   social.UserID,
+				},
+			})
+		}
+		verifiers = append(verifiers, verifier.NewStaticVerifier(socials))
+	}
+	if len(verifiers) == 1 {
+		return verifiers[0]
+	}
+	return verifier.NewCompositeVerifier(verifiers...)
+}
+
 func NewLeaderboardService(cfg config.Config) *Service {
+	return newLeaderboardService(cfg, NewDataNodeClient(cfg))
+}
+
+func newLeaderboardService(cfg config.Config, dataNode *datanode.Client) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
 	svc := &Service{
-		cfg: cfg,
+		cfg:      cfg,
+		dataNode: dataNode,
+		ctx:      ctx,
+		cancel:   cancel,
 		pricingEngine: pricing.NewEngine(url.URL{
 			Scheme: "https",
 			Host:   "prices.ops.vega.xyz",
 			Path:   "/prices",
 		}),
-		verifier: verifier.NewVerifierService(*cfg.SocialURL, cfg.TwitterBlacklist),
+		verifier:   verifier.NewVerifierService(NewVerifier(cfg, nil)),
+		exclusions: newExclusionList(cfg),
 	}
 	return svc
 }
 
+// SetVerifier replaces the source of verified socials, e.g. to add a MongoDB collection
+// once the datastore is connected. It must be called before Start.
+func (s *Service) SetVerifier(v verifier.Verifier) {
+	s.verifier = verifier.NewVerifierService(v)
+}
+
 type Service struct {
 	cfg config.Config
 
@@ -74,10 +182,49 @@ type Service struct {
 	board         Leaderboard
 	mu            sync.RWMutex
 	verifier      *verifier.Service
+
+	// exclusions come from the config, runtimeExclusions are set by the admin API and
+	// guarded by mu
+	exclusions        exclusionList
+	runtimeExclusions []config.Exclusion
+
+	// updateMu serializes updates, which run on the timer and on demand, and config reloads.
+	// Updates are skipped while paused, see Pause.
+	updateMu sync.Mutex
+	paused   int32
+
+	// dataNode is shared by the competitions of a Host. Requests use ctx, which Stop cancels.
+	dataNode *datanode.Client
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	// snapshots is optional, sequence is the last snapshot written or restored
+	snapshots SnapshotStore
+	sequence  int64
+	// cumulative is set by ByPeriodAggregate to the scores of every party it scored in the
+	// current update, and written with its snapshot
+	cumulative map[string]decimal.Decimal
+
+	// baselines is optional, algorithms using baselines fail without it
+	baselines BaselineStore
+
+	// subscribers are notified of every new board, see Subscribe
+	subscribers       map[chan struct{}]struct{}
+	subscribersMu     sync.Mutex
+	subscribersClosed bool
+
+	// reference and scores are the state of the position and score changes, see movement.go.
+	// They are guarded by updateMu.
+	reference referenceBoard
+	scores    map[string]decimal.Decimal
+
+	// assets caches asset details by ID, see asset()
+	assets   map[string]Asset
+	assetsMu sync.Mutex
 }
 
 func (s *Service) Start() {
-	log.Info("Leaderboard service started")
+	log.WithField("competition", s.ID()).Info("Leaderboard service started")
 
 	// The first time we start the service it will be
 	// in a status of "loading" as it waits for first data
@@ -95,16 +242,42 @@ func (s *Service) Start() {
 		blacklisted:    []Participant{},
 	}
 	s.board = newBoard
+	s.restoreSnapshot()
 
 	s.update()
+	// Reload resets the timer if the poll interval changes
+	s.updateMu.Lock()
 	s.timer = util.Schedule(s.update, s.cfg.VegaPoll)
+	s.updateMu.Unlock()
 }
 
 func (s *Service) Stop() {
 	if s.timer != nil {
 		s.timer.Stop()
 	}
-	log.Info("Leaderboard service stopped")
+	s.cancel()
+	s.closeSubscribers()
+	log.WithField("competition", s.ID()).Info("Leaderboard service stopped")
+}
+
+// Pause skips the timed updates until Resume, keeping the current board.
+func (s *Service) Pause() {
+	atomic.StoreInt32(&s.paused, 1)
+}
+
+// Resume restarts the timed updates from the next poll.
+func (s *Service) Resume() {
+	atomic.StoreInt32(&s.paused, 0)
+}
+
+// Paused returns true while the timed updates are paused.
+func (s *Service) Paused() bool {
+	return atomic.LoadInt32(&s.paused) == 1
+}
+
+// Recompute runs an update in the background, even while updates are paused.
+func (s *Service) Recompute() {
+	go s.recompute()
 }
 
 const (
@@ -112,10 +285,18 @@ const (
 	competitionNotStarted = "notStarted"
 	competitionActive     = "active"
 	competitionEnded      = "ended"
+	// competitionDegraded is reported instead of active while the board is stale
+	competitionDegraded = "degraded"
 )
 
 func (s *Service) Status() string {
-	now := time.Now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.statusAt(time.Now())
+}
+
+// statusAt returns the status of the competition at a time. The caller holds s.mu.
+func (s *Service) statusAt(now time.Time) string {
 	if now.Before(s.cfg.StartTime) {
 		// Competition has not yet started
 		return competitionNotStarted
@@ -128,13 +309,58 @@ func (s *Service) Status() string {
 	return competitionEnded
 }
 
+// ID returns the competition ID.
+func (s *Service) ID() string {
+	if s.cfg.ID == "" {
+		return config.DefaultCompetitionID
+	}
+	return s.cfg.ID
+}
+
+// Summary returns a short description of the competition and its current status.
+func (s *Service) Summary() CompetitionSummary {
+	status := s.Status()
+	if status == competitionActive {
+		s.mu.RLock()
+		if s.live().Status == competitionDegraded {
+			status = competitionDegraded
+		}
+		s.mu.RUnlock()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return CompetitionSummary{
+		ID:          s.ID(),
+		Description: s.cfg.Description,
+		Algorithm:   s.cfg.Algorithm,
+		StartTime:   s.cfg.StartTime,
+		EndTime:     s.cfg.EndTime,
+		Status:      status,
+		Paused:      s.Paused(),
+	}
+}
+
 func (s *Service) update() {
+	if s.Paused() {
+		log.WithField("competition", s.ID()).Info("Leaderboard updates are paused")
+		return
+	}
+	s.recompute()
+}
+
+// recompute runs an update, even while updates are paused.
+func (s *Service) recompute() {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	start := time.Now()
 	status := s.Status()
+	s.captureBaselines()
 
 	// Attempt to update parties from external social verifier service
 	// Safe approach, will only overwrite internal collection if successful
 	s.verifier.UpdateVerifiedParties()
-	// Grab a map of the verified pub-key->twitter-handle for leaderboard
+	// Grab a map of the verified pub-key->social for leaderboard
 	socials := s.verifier.PubKeysToSocials()
 	// If no verified pub-key->social-handles found, no need to query Vega
 	if len(socials) == 0 {
@@ -151,73 +377,32 @@ func (s *Service) update() {
 		return
 	}
 
-	log.Infof("Algo start: %s", s.cfg.Algorithm)
-	var p []Participant
+	log.WithField("competition", s.ID()).Infof("Algo start: %s", s.cfg.Algorithm)
 	var err error
-	switch s.cfg.Algorithm {
-	case "ByPartyAccountGeneralBalance":
-		p, err = s.sortByPartyAccountGeneralBalance(socials)
-	case "ByPartyAccountGeneralBalanceLP":
-		p, err = s.sortByPartyAccountGeneralBalanceAndLP(socials)
-	case "ByPartyAccountGeneralProfit":
-		p, err = s.sortByPartyAccountGeneralProfit(socials, false)
-	case "ByPartyAccountGeneralProfitLP":
-		p, err = s.sortByPartyAccountGeneralProfit(socials, true)
-	case "ByPartyGovernanceVotes":
-		p, err = s.sortByPartyGovernanceVotes(socials)
-	case "ByPartyGovernanceVotedList":
-		p, err = s.sortByPartyGovernanceVotedList(socials)
-	case "ByLPCommittedList":
-		p, err = s.sortByLPCommittedList(socials)
-	case "ByLPFees":
-		p, err = s.sortByLPFees(socials)
-	case "ByAssetDepositWithdrawal":
-		p, err = s.sortByAssetDepositWithdrawal(socials)
-	case "ByAssetWithdrawalLimit":
-		p, err = s.sortByAssetWithdrawalLimit(socials)
-	case "ByAssetTransfers":
-		p, err = s.sortByAssetTransfers(socials)
-	case "BySocialRegistration":
-		p, err = s.sortBySocialRegistration(s.verifier.List())
-	case "ByPartyAccountMultipleBalance":
-		p, err = s.sortByPartyAccountMultipleBalance(socials)
-	case "ByPartyAccountGeneralLoser":
-		p, err = s.sortByPartyAccountGeneralLoser(socials)
-	case "ByPartyPositions":
-		p, err = s.sortByPartyPositions(socials)
-	case "ByPartyPositionsJSON":
-		p, err = s.sortByPartyPositionsJSON(socials)
-	case "ByPartyPositionsExisting":
-		p, err = s.sortByPartyPositionsExisting(socials)
-	case "ByPartyPositionsExistingNew":
-		p, err = s.sortByPartyPositionsExistingNew(socials)
-	case "ByPartyPositionsInternal":
-		p, err = s.sortByPartyPositionsInternal(socials)
-	case "ByPartyPositionsMedianSecond":
-		p, err = s.sortByPartyPositionsMedianDay2(socials)
-	case "ByPartyPositionsMedianDay3":
-		p, err = s.sortByPartyPositionsMedianDay3(socials)
-	case "ByPartyPositionsWithTransfers":
-		p, err = s.sortByPartyPositionsWithTransfers(socials)
-	case "ByPartyPositionsWithTransfersPercentage":
-		p, err = s.sortByPartyPositionsWithTransfersPercentage(socials)
-	case "ByPartyRewardsMakerPaid":
-		p, err = s.sortByPartyRewardsMakerPaid(socials)
-	case "ByPartyRewardsMakerReceived":
-		p, err = s.sortByPartyRewardsMakerReceived(socials)
-	case "ByPartyRewardsMakerReceivedPubkeys":
-		p, err = s.sortByPartyRewardsMakerReceivedPubkeys(socials)
-	case "ByPartyPositionsPubkeys":
-		p, err = s.sortByPartyPositionsPubkeys(socials)
-	case "ByPartyDepositWithdrawalPubkeys":
-		p, err = s.sortByPartyDepositWithdrawalPubkeys(socials)
-	default:
+	var p []Participant
+	algo, found := LookupAlgorithm(s.cfg.Algorithm)
+	if !found {
 		err = fmt.Errorf("invalid algorithm: %s", s.cfg.Algorithm)
+	} else {
+		p, err = algo.Score(s, socials)
 	}
+	metrics.ObserveUpdate(s.ID(), s.cfg.Algorithm, time.Since(start), err)
 	if err != nil {
-		log.WithError(err).Warn("Failed to sort")
-		p = []Participant{}
+		// Publishing a partial fetch would drop or misrank participants, so the last
+		// good board is kept and marked as stale instead
+		log.WithError(err).WithField("competition", s.ID()).Warn("Failed to sort")
+		s.mu.Lock()
+		s.board.Status = status
+		s.board.Freshness = s.board.Freshness.failed(util.UnixTimestampUtcNowFormatted(), err)
+		s.mu.Unlock()
+		return
+	}
+	for i := range p {
+		p[i].Identity = socials[p[i].PublicKey].Identity
+		p[i].Score = p[i].sortNum.String()
 	}
+	s.excludeParticipants(s.currentExclusions(), p, time.Now())
+	s.breakTies(p, socials)
 
 	// Filter into two sets to separate blacklisted users
 	include := []Participant{}
@@ -229,11 +414,17 @@ func (s *Service) update() {
 			include = append(include, ppt)
 		}
 	}
+	if ranker, ok := algo.(ExclusionRanker); ok && ranker.RanksExcluded() {
+		include, exclude = exclude, include
+	}
 	include = s.AllocatePositions(include)
 	exclude = s.AllocatePositions(exclude)
+	referenceTime := s.trackMovement(include, exclude, time.Now())
+	metrics.SetParticipants(s.ID(), len(socials), len(include), len(exclude))
 
-	log.Infof("Algo finish: %s", s.cfg.Algorithm)
+	log.WithField("competition", s.ID()).Infof("Algo finish: %s", s.cfg.Algorithm)
 
+	now := util.UnixTimestampUtcNowFormatted()
 	s.mu.Lock()
 	newBoard := Leaderboard{
 		Version:        1,
@@ -242,84 +433,64 @@ func (s *Service) update() {
 		DefaultSort:    s.cfg.DefaultSort,
 		Description:    s.cfg.Description,
 		Headers:        s.cfg.Headers,
-		LastUpdate:     util.UnixTimestampUtcNowFormatted(),
+		LastUpdate:     now,
 		Status:         status,
+		Freshness:      s.board.Freshness.succeeded(now),
+		ReferenceTime:  referenceTime,
+		Participants:   include,
+		blacklisted:    exclude,
 	}
-	// Seems like sometime the participants list is empty
-	// in that case we just reuse the previous
-	// board participants
-	if len(include) > 0 {
-		newBoard.Participants = include
-	} else {
-		newBoard.Participants = s.board.Participants
-	}
-	if len(exclude) > 0 {
-		newBoard.blacklisted = exclude
-	} else {
-		newBoard.blacklisted = s.board.blacklisted
-	}
-
 	s.board = newBoard
 	s.mu.Unlock()
-	log.WithFields(log.Fields{"participants": len(s.board.Participants)}).Info("Leaderboard updated")
+	log.WithFields(log.Fields{"competition": s.ID(), "participants": len(newBoard.Participants)}).Info("Leaderboard updated")
+	s.notifySubscribers()
+
+	s.saveSnapshot(newBoard)
 }
 
-func (s *Service) CsvLeaderboard(q string, skip int64, size int64, blacklisted bool) ([]byte, error) {
+func (s *Service) CsvLeaderboard(q string, skip int64, size int64, blacklisted bool, by Sort) ([]byte, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	// Filter based on blacklisted or regular leaderboard participants
-	target := s.board.Participants
-	if blacklisted {
-		target = s.board.blacklisted
-	}
-
-	participants := []Participant{}
-	if q == "" {
-		// No search query filter found
-		// Full data set required
-		participants = target
-	} else {
-		// Search query has been passed with request
-		q = strings.ToLower(q)
-		for _, p := range target {
-			pubKey := strings.ToLower(p.PublicKey)
-			// case insensitive comparison
-			if pubKey == q || strings.Contains(pubKey, q) {
-				participants = append(participants, p)
-			}
-		}
-		// Filtered data set with search query
-	}
-
-	board := Leaderboard{
-		Version:        s.board.Version,
-		Assets:         s.board.Assets,
-		LastUpdate:     s.board.LastUpdate,
-		Headers:        s.board.Headers,
-		Description:    s.board.Description,
-		DefaultSort:    s.board.DefaultSort,
-		DefaultDisplay: s.board.DefaultDisplay,
-		Status:         s.board.Status,
-		Participants:   s.paginate(participants, skip, size),
+	board, err := s.view(s.live(), q, skip, size, blacklisted, by)
+	if err != nil {
+		return nil, err
 	}
-
 	return s.WriteParticipantsToCsvBytes(board.Participants)
 }
 
-func (s *Service) JsonLeaderboard(q string, skip int64, size int64, blacklisted bool) ([]byte, error) {
+func (s *Service) JsonLeaderboard(q string, skip int64, size int64, blacklisted bool, by Sort) ([]byte, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	board, err := s.view(s.live(), q, skip, size, blacklisted, by)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(board)
+}
+
+// view returns a copy of the board holding the requested page of the filtered participants,
+// re-ranked if a sort is given.
+func (s *Service) view(source Leaderboard, q string, skip int64, size int64, blacklisted bool, by Sort) (Leaderboard, error) {
+	by, err := s.resolveSort(by)
+	if err != nil {
+		return Leaderboard{}, err
+	}
+
 	// Filter based on blacklisted or regular leaderboard participants
-	target := s.board.Participants
+	target := source.Participants
 	if blacklisted {
-		target = s.board.blacklisted
+		target = source.blacklisted
+	}
+	if by.Metric != "" {
+		target = s.sorted(target, by)
 	}
 
 	participants := []Participant{}
 	if q == "" {
 		// No search query filter found
+		// Full data set required
 		participants = target
 	} else {
 		// Search query has been passed with request
@@ -334,19 +505,19 @@ func (s *Service) JsonLeaderboard(q string, skip int64, size int64, blacklisted
 		// Filtered data set with search query
 	}
 
-	board := Leaderboard{
-		Version:        s.board.Version,
-		Assets:         s.board.Assets,
-		LastUpdate:     s.board.LastUpdate,
-		Headers:        s.board.Headers,
-		Description:    s.board.Description,
-		DefaultSort:    s.board.DefaultSort,
-		DefaultDisplay: s.board.DefaultDisplay,
-		Status:         s.board.Status,
+	return Leaderboard{
+		Version:        source.Version,
+		Assets:         source.Assets,
+		LastUpdate:     source.LastUpdate,
+		Headers:        source.Headers,
+		Description:    source.Description,
+		DefaultSort:    source.DefaultSort,
+		DefaultDisplay: source.DefaultDisplay,
+		Status:         source.Status,
+		Freshness:      source.Freshness,
+		ReferenceTime:  source.ReferenceTime,
 		Participants:   s.paginate(participants, skip, size),
-	}
-
-	return json.Marshal(board)
+	}, nil
 }
 
 func (s *Service) paginate(p []Participant, skip int64, size int64) []Participant {
@@ -371,10 +542,19 @@ func (s *Service) WriteParticipantsToCsvBytes(participants []Participant) (resul
 		csvData := make([]util.ParticipantCsvEntry, 0)
 		for _, p := range participants {
 			csv := util.ParticipantCsvEntry{
-				Position:   p.Position,
-				VegaPubKey: p.PublicKey,
-				CreatedAt:  p.CreatedAt,
-				UpdatedAt:  p.UpdatedAt,
+				Position:       p.Position,
+				Provider:       p.Identity.Provider,
+				Handle:         p.Identity.Handle,
+				ProviderUserID: p.Identity.UserID,
+				VegaPubKey:     p.PublicKey,
+				CreatedAt:      p.CreatedAt,
+				UpdatedAt:      p.UpdatedAt,
+
+				PreviousPosition:  p.PreviousPosition,
+				PositionChange:    p.PositionChange,
+				ReferencePosition: p.ReferencePosition,
+				ReferenceChange:   p.ReferenceChange,
+				ScoreChange:       p.ScoreChange,
 			}
 			for i, d := range p.Data {
 				if i > 0 {
@@ -389,16 +569,21 @@ func (s *Service) WriteParticipantsToCsvBytes(participants []Participant) (resul
 			log.WithError(err).Error("Error marshaling Participant data to CSV bytes")
 			return make([]byte, 0), err
 		}
-		return res, nil
+		return appendMetricColumns(res, participants)
 	}
 	return make([]byte, 0), nil
 }
 
+// AllocatePositions numbers participants, which must already be in rank order. With the
+// shared ranking mode participants with the same score get the same position, e.g. 1, 2, 2, 4.
 func (s *Service) AllocatePositions(p []Participant) []Participant {
-	i := 0
-	for range p {
+	shared := s.cfg.Ranking == config.RankingShared
+	for i := range p {
+		if shared && i > 0 && p[i].sortNum.Equal(p[i-1].sortNum) {
+			p[i].Position = p[i-1].Position
+			continue
+		}
 		p[i].Position = i + 1 // humans want 1-indexed lists :-|
-		i++
 	}
 	return p
 }
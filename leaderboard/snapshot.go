@@ -0,0 +1,195 @@
+package leaderboard
+
+import (
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/datastore"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Snapshot is a persisted copy of a leaderboard, written after every successful update.
+type Snapshot struct {
+	Competition  string        `bson:"competition"`
+	Sequence     int64         `bson:"sequence"`
+	Timestamp    time.Time     `bson:"timestamp"`
+	LastUpdate   string        `bson:"last_update"`
+	Status       string        `bson:"status"`
+	Participants []Participant `bson:"participants"`
+	Blacklisted  []Participant `bson:"blacklisted"`
+
+	// Cumulative is the unformatted score of every party scored by ByPeriodAggregate, by
+	// public key, including parties left off the board. Period boundaries are read from it.
+	Cumulative map[string]string `bson:"cumulative,omitempty"`
+}
+
+// SnapshotStore persists leaderboard snapshots.
+type SnapshotStore interface {
+	// SaveSnapshot stores a new snapshot.
+	SaveSnapshot(snap Snapshot) error
+
+	// LatestSnapshot returns the most recent snapshot of a competition, or nil if there is none.
+	LatestSnapshot(competition string) (*Snapshot, error)
+
+	// SnapshotBefore returns the last snapshot taken at or before a time, or nil if there is none.
+	SnapshotBefore(competition string, at time.Time) (*Snapshot, error)
+
+	// SnapshotAfter returns the first snapshot taken after a time, or nil if there is none.
+	SnapshotAfter(competition string, at time.Time) (*Snapshot, error)
+
+	// ParticipantHistory returns, oldest first, every snapshot the public key appears in.
+	// Each snapshot holds only that participant and no blacklisted participants.
+	ParticipantHistory(competition string, publicKey string) ([]Snapshot, error)
+}
+
+// MongoSnapshotStore keeps snapshots of every competition in a single MongoDB collection.
+type MongoSnapshotStore struct {
+	ds             *datastore.Service
+	databaseName   string
+	collectionName string
+}
+
+// NewMongoSnapshotStore creates a SnapshotStore using a connected datastore.
+func NewMongoSnapshotStore(ds *datastore.Service, databaseName string, collectionName string) *MongoSnapshotStore {
+	return &MongoSnapshotStore{
+		ds:             ds,
+		databaseName:   databaseName,
+		collectionName: collectionName,
+	}
+}
+
+// EnsureIndexes creates the indexes the snapshot queries use. A positive retention also adds
+// a TTL index, so MongoDB deletes snapshots older than it.
+func (m *MongoSnapshotStore) EnsureIndexes(retention time.Duration) error {
+	indexes := []bson.D{
+		{{Key: "competition", Value: 1}, {Key: "timestamp", Value: -1}},
+		{{Key: "competition", Value: 1}, {Key: "sequence", Value: -1}},
+	}
+	for _, keys := range indexes {
+		if err := m.ds.CreateIndex(m.databaseName, m.collectionName, keys, 0); err != nil {
+			return err
+		}
+	}
+	if retention > 0 {
+		return m.ds.CreateIndex(m.databaseName, m.collectionName, bson.D{{Key: "timestamp", Value: 1}}, retention)
+	}
+	return nil
+}
+
+func (m *MongoSnapshotStore) SaveSnapshot(snap Snapshot) error {
+	return m.ds.InsertDocument(m.databaseName, m.collectionName, snap)
+}
+
+func (m *MongoSnapshotStore) LatestSnapshot(competition string) (*Snapshot, error) {
+	// Sequences restart from the restored snapshot, so a replica or a restart without the
+	// latest snapshot can reuse them, timestamps decide
+	return m.findOne(
+		bson.D{{Key: "competition", Value: competition}},
+		bson.D{{Key: "timestamp", Value: -1}, {Key: "sequence", Value: -1}},
+	)
+}
+
+func (m *MongoSnapshotStore) SnapshotBefore(competition string, at time.Time) (*Snapshot, error) {
+	return m.findOne(
+		bson.D{{Key: "competition", Value: competition}, {Key: "timestamp", Value: bson.D{{Key: "$lte", Value: at}}}},
+		bson.D{{Key: "timestamp", Value: -1}},
+	)
+}
+
+func (m *MongoSnapshotStore) SnapshotAfter(competition string, at time.Time) (*Snapshot, error) {
+	return m.findOne(
+		bson.D{{Key: "competition", Value: competition}, {Key: "timestamp", Value: bson.D{{Key: "$gt", Value: at}}}},
+		bson.D{{Key: "timestamp", Value: 1}},
+	)
+}
+
+func (m *MongoSnapshotStore) ParticipantHistory(competition string, publicKey string) ([]Snapshot, error) {
+	snaps := []Snapshot{}
+	err := m.ds.FindDocuments(
+		m.databaseName,
+		m.collectionName,
+		bson.D{{Key: "competition", Value: competition}, {Key: "participants.pub_key", Value: publicKey}},
+		bson.D{{Key: "timestamp", Value: 1}},
+		bson.D{
+			{Key: "blacklisted", Value: 0},
+			{Key: "participants", Value: bson.D{{Key: "$elemMatch", Value: bson.D{{Key: "pub_key", Value: publicKey}}}}},
+		},
+		&snaps,
+	)
+	return snaps, err
+}
+
+func (m *MongoSnapshotStore) findOne(filter bson.D, sort bson.D) (*Snapshot, error) {
+	var snap Snapshot
+	found, err := m.ds.FindOneDocument(m.databaseName, m.collectionName, filter, sort, &snap)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// SetSnapshotStore enables snapshots. It must be called before Start.
+func (s *Service) SetSnapshotStore(store SnapshotStore) {
+	s.snapshots = store
+}
+
+// restoreSnapshot loads the latest snapshot into the board, so a restarted service
+// serves the last known results rather than an empty, loading board.
+func (s *Service) restoreSnapshot() {
+	if s.snapshots == nil {
+		return
+	}
+	snap, err := s.snapshots.LatestSnapshot(s.ID())
+	if err != nil {
+		log.WithError(err).WithField("competition", s.ID()).Warn("Failed to load latest snapshot")
+		return
+	}
+	if snap == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sequence = snap.Sequence
+	s.board.LastUpdate = snap.LastUpdate
+	s.board.Status = snap.Status
+	s.board.Freshness = s.board.Freshness.succeeded(snap.LastUpdate)
+	s.board.Participants = snap.Participants
+	s.board.blacklisted = snap.Blacklisted
+	for i := range s.board.blacklisted {
+		s.board.blacklisted[i].isBlacklisted = true
+	}
+	log.WithFields(log.Fields{
+		"competition":  s.ID(),
+		"sequence":     snap.Sequence,
+		"participants": len(snap.Participants),
+	}).Info("Restored leaderboard snapshot")
+}
+
+// saveSnapshot writes the given board as the next snapshot.
+func (s *Service) saveSnapshot(board Leaderboard) {
+	if s.snapshots == nil {
+		return
+	}
+	snap := Snapshot{
+		Competition:  s.ID(),
+		Sequence:     s.sequence + 1,
+		Timestamp:    time.Now().UTC(),
+		LastUpdate:   board.LastUpdate,
+		Status:       board.Status,
+		Participants: board.Participants,
+		Blacklisted:  board.blacklisted,
+	}
+	if len(s.cumulative) > 0 {
+		snap.Cumulative = make(map[string]string, len(s.cumulative))
+		for partyID, score := range s.cumulative {
+			snap.Cumulative[partyID] = score.String()
+		}
+	}
+	if err := s.snapshots.SaveSnapshot(snap); err != nil {
+		log.WithError(err).WithField("competition", s.ID()).Warn("Failed to save snapshot")
+		return
+	}
+	s.sequence = snap.Sequence
+}
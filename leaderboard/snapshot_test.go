@@ -0,0 +1,121 @@
+package leaderboard_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memorySnapshotStore struct {
+	mu        sync.Mutex
+	snapshots []leaderboard.Snapshot
+}
+
+func (m *memorySnapshotStore) SaveSnapshot(snap leaderboard.Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshots = append(m.snapshots, snap)
+	return nil
+}
+
+func (m *memorySnapshotStore) LatestSnapshot(competition string) (*leaderboard.Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var latest *leaderboard.Snapshot
+	for i, snap := range m.snapshots {
+		if snap.Competition == competition && (latest == nil || !snap.Timestamp.Before(latest.Timestamp)) {
+			latest = &m.snapshots[i]
+		}
+	}
+	return latest, nil
+}
+
+func (m *memorySnapshotStore) SnapshotBefore(competition string, at time.Time) (*leaderboard.Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var found *leaderboard.Snapshot
+	for i, snap := range m.snapshots {
+		if snap.Competition == competition && !snap.Timestamp.After(at) && (found == nil || snap.Timestamp.After(found.Timestamp)) {
+			found = &m.snapshots[i]
+		}
+	}
+	return found, nil
+}
+
+func (m *memorySnapshotStore) SnapshotAfter(competition string, at time.Time) (*leaderboard.Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var found *leaderboard.Snapshot
+	for i, snap := range m.snapshots {
+		if snap.Competition == competition && snap.Timestamp.After(at) && (found == nil || snap.Timestamp.Before(found.Timestamp)) {
+			found = &m.snapshots[i]
+		}
+	}
+	return found, nil
+}
+
+func (m *memorySnapshotStore) ParticipantHistory(competition string, publicKey string) ([]leaderboard.Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	history := []leaderboard.Snapshot{}
+	for _, snap := range m.snapshots {
+		if snap.Competition != competition {
+			continue
+		}
+		for _, p := range snap.Participants {
+			if p.PublicKey == publicKey {
+				snap.Participants = []leaderboard.Participant{p}
+				snap.Blacklisted = nil
+				history = append(history, snap)
+				break
+			}
+		}
+	}
+	return history, nil
+}
+
+func TestSnapshotSavedAfterUpdate(t *testing.T) {
+	store := &memorySnapshotStore{}
+	svc := leaderboard.NewLeaderboardService(newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"}))
+	svc.SetSnapshotStore(store)
+	svc.Start()
+	svc.Stop()
+
+	require.Len(t, store.snapshots, 1)
+	snap := store.snapshots[0]
+	require.Equal(t, "default", snap.Competition)
+	require.Equal(t, int64(1), snap.Sequence)
+	require.Equal(t, "active", snap.Status)
+	require.Len(t, snap.Participants, 2)
+	require.Equal(t, "p2", snap.Participants[0].PublicKey)
+}
+
+func TestSnapshotRestoredOnStart(t *testing.T) {
+	store := &memorySnapshotStore{}
+	store.snapshots = []leaderboard.Snapshot{{
+		Competition:  "default",
+		Sequence:     7,
+		LastUpdate:   "1600000000",
+		Status:       "ended",
+		Participants: []leaderboard.Participant{{Position: 1, PublicKey: "restored", Data: []string{"1"}}},
+	}}
+
+	// The competition has ended, so no update runs and the restored board is served
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"})
+	cfg.StartTime = cfg.StartTime.AddDate(-1, 0, 0)
+	cfg.EndTime = cfg.StartTime.AddDate(0, 0, 1)
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.SetSnapshotStore(store)
+	svc.Start()
+	defer svc.Stop()
+
+	payload, err := svc.JsonLeaderboard("", 0, 0, false, leaderboard.Sort{})
+	require.NoError(t, err)
+	require.Contains(t, string(payload), `"publicKey":"restored"`)
+	require.Contains(t, string(payload), `"status":"ended"`)
+	require.Len(t, store.snapshots, 1)
+}
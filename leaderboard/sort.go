@@ -0,0 +1,148 @@
+package leaderboard
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vegaprotocol/topgun-service/config"
+
+	"github.com/shopspring/decimal"
+)
+
+// Sort orders.
+const (
+	SortAscending  = "asc"
+	SortDescending = "desc"
+)
+
+var (
+	// ErrUnknownMetric is returned for a sort by a metric the algorithm does not return.
+	ErrUnknownMetric = errors.New("unknown sort metric")
+	// ErrInvalidOrder is returned for a sort order other than asc or desc.
+	ErrInvalidOrder = errors.New("invalid sort order, use asc or desc")
+)
+
+// Sort re-ranks a view of the board by one of the algorithm's metrics, see MetricNames. The
+// zero Sort keeps the algorithm's ranking.
+type Sort struct {
+	Metric string
+	Order  string
+}
+
+// NewSort checks a requested sort. The order defaults to descending, and is ignored without
+// a metric.
+func NewSort(metric string, order string) (Sort, error) {
+	order = strings.ToLower(order)
+	switch order {
+	case "":
+		order = SortDescending
+	case SortAscending, SortDescending:
+	default:
+		return Sort{}, ErrInvalidOrder
+	}
+	if metric == "" {
+		return Sort{}, nil
+	}
+	return Sort{Metric: metric, Order: order}, nil
+}
+
+// CheckSort returns ErrUnknownMetric if the algorithm does not return the metric of a sort.
+func (s *Service) CheckSort(by Sort) error {
+	_, err := s.resolveSort(by)
+	return err
+}
+
+// resolveSort replaces the metric of a sort with the algorithm's spelling of its name.
+func (s *Service) resolveSort(by Sort) (Sort, error) {
+	if by.Metric == "" {
+		return by, nil
+	}
+	names := []string{}
+	if algo, found := LookupAlgorithm(s.cfg.Algorithm); found {
+		names = algo.MetricNames()
+	}
+	name, found := metricName(names, by.Metric)
+	if !found {
+		return Sort{}, fmt.Errorf("%w: %s (available: %s)", ErrUnknownMetric, by.Metric, strings.Join(names, ", "))
+	}
+	by.Metric = name
+	return by, nil
+}
+
+// metricName finds a metric name, compared case-insensitively.
+func metricName(names []string, name string) (string, bool) {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return n, true
+		}
+	}
+	return "", false
+}
+
+// sorted returns a copy of participants ordered by a metric, positioned for that order.
+// Participants without the metric come last. Ties keep the algorithm's order, and share a
+// position with the shared ranking mode.
+func (s *Service) sorted(participants []Participant, by Sort) []Participant {
+	type entry struct {
+		metric Metric
+		found  bool
+	}
+	entries := make([]entry, len(participants))
+	order := make([]int, len(participants))
+	for i, p := range participants {
+		entries[i].metric, entries[i].found = p.Metrics.Get(by.Metric)
+		order[i] = i
+	}
+	compare := func(a, b int) int {
+		c := compareMetrics(entries[a].metric, entries[b].metric)
+		if by.Order == SortDescending {
+			c = -c
+		}
+		return c
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if entries[a].found != entries[b].found {
+			return entries[a].found
+		}
+		return compare(a, b) < 0
+	})
+
+	shared := s.cfg.Ranking == config.RankingShared
+	result := make([]Participant, len(participants))
+	for i, index := range order {
+		result[i] = participants[index]
+		previous := i - 1
+		if shared && i > 0 && entries[order[previous]].found == entries[index].found && compare(order[previous], index) == 0 {
+			result[i].Position = result[previous].Position
+			continue
+		}
+		result[i].Position = i + 1
+	}
+	return result
+}
+
+// compareMetrics compares two values of the same metric, numerically unless it is text.
+func compareMetrics(a Metric, b Metric) int {
+	if a.Type == MetricText || b.Type == MetricText {
+		return strings.Compare(a.Value, b.Value)
+	}
+	return metricNumber(a).Cmp(metricNumber(b))
+}
+
+func metricNumber(m Metric) decimal.Decimal {
+	if m.Type == MetricBoolean {
+		if m.Value == "true" {
+			return decimal.NewFromInt(1)
+		}
+		return decimal.Zero
+	}
+	// Values that do not parse, e.g. from an older snapshot, sort as zero
+	d, err := decimal.NewFromString(m.Value)
+	if err != nil {
+		return decimal.Zero
+	}
+	return d
+}
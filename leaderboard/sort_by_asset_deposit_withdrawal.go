@@ -1,77 +1,40 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"sort"
-	"strconv"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByAssetDepositWithdrawal",
+		nil,
+		[]string{"depositedAndWithdrew"},
+		(*Service).sortByAssetDepositWithdrawal,
+	))
+}
+
 func (s *Service) sortByAssetDepositWithdrawal(socials map[string]verifier.Social) ([]Participant, error) {
 
 	// The minimum number of unique deposits and withdrawals needed to achieve this reward
 	minDepositAndWithdrawals := 1
-	minWithdrawalThreshold := 0
-	minDepositThreshold := 0
+	minWithdrawalThreshold := decimal.Zero
+	minDepositThreshold := decimal.Zero
 
 	// Default: 1 unique asset deposit and 1 unique withdrawal1 from the erc20 bridge
 
-	gqlQuery := `{
-		partiesConnection () {
-		  edges {
-			node {
-			  id
-			  depositsConnection {
-				edges {
-				  node {
-					amount
-					createdTimestamp
-					creditedTimestamp
-					status
-					asset {
-					  id
-					  symbol
-					  source {
-						__typename
-					  }
-					}
-				  }
-				}
-			  }
-			  withdrawalsConnection {
-				edges {
-				  node {
-					amount
-					createdTimestamp
-					createdTimestamp
-					status
-					asset {
-					  id
-					  symbol
-					  source {
-						__typename
-					  }
-					}
-				  }
-				}
-			  }
-			}
-		  }
-		}
-	  }`
+	// Counted amounts are listed in whole units of the asset
+	asset, err := s.settlementAsset()
+	if err != nil {
+		return nil, err
+	}
 
-	ctx := context.Background()
+	ctx := s.ctx
 
-	parties, err := getParties(
-		ctx,
-		s.cfg.VegaGraphQLURL.String(),
-		gqlQuery,
-		map[string]string{"assetId": s.cfg.VegaAssets[0]},
-		nil,
-	)
+	parties, err := s.fetchParties(ctx, socialKeys(socials), "deposits", "withdrawals")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
@@ -82,15 +45,13 @@ func (s *Service) sortByAssetDepositWithdrawal(socials map[string]verifier.Socia
 		withdrawalCount := 0
 		depositCount := 0
 		for _, w := range party.WithdrawalsConnection.Edges {
-			// string to int
-			amount, err := strconv.Atoi(w.Withdrawal.Amount)
+			amount, err := parseAmount(w.Withdrawal.Amount)
 			if err != nil {
-				fmt.Errorf("failed to convert Withdrawal amount to string", err)
+				return nil, err
 			}
-
 			if w.Withdrawal.Asset.Id == s.cfg.VegaAssets[0] &&
 				w.Withdrawal.Status == "STATUS_FINALIZED" &&
-				amount >= minWithdrawalThreshold &&
+				amount.GreaterThanOrEqual(minWithdrawalThreshold) &&
 				w.Withdrawal.CreatedAt.After(s.cfg.StartTime) &&
 				w.Withdrawal.CreatedAt.Before(s.cfg.EndTime) {
 				withdrawalCount++
@@ -98,15 +59,13 @@ func (s *Service) sortByAssetDepositWithdrawal(socials map[string]verifier.Socia
 		}
 
 		for _, d := range party.DepositsConnection.Edges {
-			// string to int
-			amount, err := strconv.Atoi(d.Deposit.Amount)
+			amount, err := parseAmount(d.Deposit.Amount)
 			if err != nil {
-				fmt.Errorf("failed to convert Withdrawal amount to string", err)
+				return nil, err
 			}
-
 			if d.Deposit.Asset.Id == s.cfg.VegaAssets[0] &&
 				d.Deposit.Status == "STATUS_FINALIZED" &&
-				amount >= minDepositThreshold &&
+				amount.GreaterThanOrEqual(minDepositThreshold) &&
 				d.Deposit.CreatedAt.After(s.cfg.StartTime) &&
 				d.Deposit.CreatedAt.Before(s.cfg.EndTime) {
 				depositCount++
@@ -116,22 +75,27 @@ func (s *Service) sortByAssetDepositWithdrawal(socials map[string]verifier.Socia
 		totalCount := withdrawalCount + depositCount
 
 		if totalCount > (minDepositAndWithdrawals - 1) {
+			breakdown := s.competitionBreakdown(&party, asset)
+			breakdown.addTerm("depositCount", decimal.NewFromInt(int64(depositCount)), false)
+			breakdown.addTerm("withdrawalCount", decimal.NewFromInt(int64(withdrawalCount)), false)
+			breakdown.Notes = append(breakdown.Notes, fmt.Sprintf(
+				"listed for at least %d finalized deposit or withdrawal in the competition", minDepositAndWithdrawals))
+			breakdown.Score = decimal.NewFromInt(int64(totalCount)).String()
+
 			utcNow := time.Now().UTC()
 			participants = append(participants, Participant{
-				PublicKey:     party.ID,
-				Data:          []string{"Deposit and Withdrawal Completed"},
-				CreatedAt:     utcNow,
-				UpdatedAt:     utcNow,
-				isBlacklisted: party.blacklisted,
+				PublicKey: party.ID,
+				Data:      []string{"Deposit and Withdrawal Completed"},
+				Metrics:   Metrics{flagMetric("depositedAndWithdrew")},
+				breakdown: breakdown,
+				CreatedAt: utcNow,
+				UpdatedAt: utcNow,
 			})
 		}
 
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum < participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortAscending(participants)
 
 	return participants, nil
 }
@@ -1,54 +1,35 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"sort"
-	"strconv"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByAssetTransfers",
+		nil,
+		[]string{"transfers"},
+		(*Service).sortByAssetTransfers,
+	))
+}
+
 func (s *Service) sortByAssetTransfers(socials map[string]verifier.Social) ([]Participant, error) {
 	// The minimum number of unique withdrawals needed to achieve this reward
-	minTransferThreshold := 4
+	minTransferThreshold := decimal.NewFromInt(4)
 
-	gqlQueryPartiesAccounts := `{
-		partiesConnection {
-		  edges {
-			node {
-			  id
-			  transfersConnection {
-				edges {
-				  node {
-					id
-					fromAccountType
-					toAccountType
-					from
-					amount
-					timestamp
-					asset {
-					  id
-					  name
-					}
-				  }
-				}
-			  }
-			}
-		  }
-		}
-	  }`
+	// Counted amounts are listed in whole units of the asset
+	asset, err := s.settlementAsset()
+	if err != nil {
+		return nil, err
+	}
 
-	ctx := context.Background()
+	ctx := s.ctx
 
-	parties, err := getParties(
-		ctx,
-		s.cfg.VegaGraphQLURL.String(),
-		gqlQueryPartiesAccounts,
-		map[string]string{"assetId": s.cfg.VegaAssets[0]},
-		nil,
-	)
+	parties, err := s.fetchParties(ctx, socialKeys(socials), "allTransfers")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
@@ -57,45 +38,52 @@ func (s *Service) sortByAssetTransfers(socials map[string]verifier.Social) ([]Pa
 	participants := []Participant{}
 	for _, party := range sParties {
 		transferCount := 0
+		counted := map[string]bool{}
 		if len(party.TransfersConnection.Edges) != 0 {
 			for _, w := range party.TransfersConnection.Edges {
-				// string to int
-				amount, err := strconv.Atoi(w.Transfer.Amount)
+				amount, err := parseAmount(w.Transfer.Amount)
 				if err != nil {
-					fmt.Errorf("failed to convert Transfer amount to string", err)
+					return nil, err
 				}
 				if w.Transfer.Asset.Id == s.cfg.VegaAssets[0] &&
-					amount >= minTransferThreshold &&
+					amount.GreaterThanOrEqual(minTransferThreshold) &&
 					w.Transfer.Timestamp.After(s.cfg.StartTime) &&
 					w.Transfer.Timestamp.Before(s.cfg.EndTime) {
 					transferCount++
+					counted[w.Transfer.Id] = true
 				}
 			}
 
 		}
 
-		var sortNum float64
-		transferCountStr := strconv.FormatFloat(float64(transferCount), 'f', int(0), 32)
-		sortNum = float64(transferCount)
+		sortNum := decimal.NewFromInt(int64(transferCount))
+		transferCountStr := sortNum.String()
 
 		if transferCount > 0 {
+			breakdown := s.competitionBreakdown(&party, asset)
+			countAmounts(breakdown.Transfers, func(t AmountBreakdown) bool {
+				return counted[t.ID]
+			})
+			breakdown.addTerm("transferCount", sortNum, false)
+			breakdown.Notes = append(breakdown.Notes, fmt.Sprintf(
+				"only transfers of at least %s in the smallest unit of the asset are counted", minTransferThreshold))
+			breakdown.Score = sortNum.String()
+
 			utcNow := time.Now().UTC()
 			participants = append(participants, Participant{
-				PublicKey:     party.ID,
-				Data:          []string{transferCountStr},
-				sortNum:       sortNum,
-				CreatedAt:     utcNow,
-				UpdatedAt:     utcNow,
-				isBlacklisted: party.blacklisted,
+				PublicKey: party.ID,
+				Data:      []string{transferCountStr},
+				Metrics:   Metrics{countMetric("transfers", int64(transferCount), "transfers")},
+				breakdown: breakdown,
+				sortNum:   sortNum,
+				CreatedAt: utcNow,
+				UpdatedAt: utcNow,
 			})
 		}
 
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum > participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortDescending(participants)
 
 	return participants, nil
 }
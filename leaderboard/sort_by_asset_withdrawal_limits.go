@@ -1,55 +1,35 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"sort"
-	"strconv"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByAssetWithdrawalLimit",
+		nil,
+		[]string{"withdrew"},
+		(*Service).sortByAssetWithdrawalLimit,
+	))
+}
+
 func (s *Service) sortByAssetWithdrawalLimit(socials map[string]verifier.Social) ([]Participant, error) {
 	// The minimum number of unique withdrawals needed to achieve this reward
-	minWithdrawalThreshold := 0
+	minWithdrawalThreshold := decimal.Zero
 
-	gqlQuery := `{
-		partiesConnection {
-		  edges {
-			node {
-			  id
-			  withdrawalsConnection {
-				edges {
-				  node {
-					amount
-					createdTimestamp
-					createdTimestamp
-					status
-					asset {
-					  id
-					  symbol
-					  source {
-						__typename
-					  }
-					}
-				  }
-				}
-			  }
-			}
-		  }
-		}
-	  }`
+	// Counted amounts are listed in whole units of the asset
+	asset, err := s.settlementAsset()
+	if err != nil {
+		return nil, err
+	}
 
-	ctx := context.Background()
+	ctx := s.ctx
 
-	parties, err := getParties(
-		ctx,
-		s.cfg.VegaGraphQLURL.String(),
-		gqlQuery,
-		map[string]string{"assetId": s.cfg.VegaAssets[0]},
-		nil,
-	)
+	parties, err := s.fetchParties(ctx, socialKeys(socials), "withdrawals")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
@@ -59,15 +39,13 @@ func (s *Service) sortByAssetWithdrawalLimit(socials map[string]verifier.Social)
 	for _, party := range sParties {
 		withdrawalCount := 0
 		for _, w := range party.WithdrawalsConnection.Edges {
-			// string to int
-			amount, err := strconv.Atoi(w.Withdrawal.Amount)
+			amount, err := parseAmount(w.Withdrawal.Amount)
 			if err != nil {
-				fmt.Errorf("failed to convert Withdrawal amount to string", err)
+				return nil, err
 			}
-
 			if w.Withdrawal.Asset.Id == s.cfg.VegaAssets[0] &&
 				w.Withdrawal.Status == "STATUS_FINALIZED" &&
-				amount >= minWithdrawalThreshold &&
+				amount.GreaterThanOrEqual(minWithdrawalThreshold) &&
 				w.Withdrawal.CreatedAt.After(s.cfg.StartTime) &&
 				w.Withdrawal.CreatedAt.Before(s.cfg.EndTime) {
 				withdrawalCount++
@@ -75,22 +53,25 @@ func (s *Service) sortByAssetWithdrawalLimit(socials map[string]verifier.Social)
 		}
 
 		if withdrawalCount > 0 {
+			breakdown := s.competitionBreakdown(&party, asset)
+			breakdown.addTerm("withdrawalCount", decimal.NewFromInt(int64(withdrawalCount)), false)
+			breakdown.Notes = append(breakdown.Notes, "listed for a finalized withdrawal in the competition")
+			breakdown.Score = decimal.NewFromInt(int64(withdrawalCount)).String()
+
 			utcNow := time.Now().UTC()
 			participants = append(participants, Participant{
-				PublicKey:     party.ID,
-				Data:          []string{"Withdrawal Completed"},
-				CreatedAt:     utcNow,
-				UpdatedAt:     utcNow,
-				isBlacklisted: party.blacklisted,
+				PublicKey: party.ID,
+				Data:      []string{"Withdrawal Completed"},
+				Metrics:   Metrics{flagMetric("withdrew")},
+				breakdown: breakdown,
+				CreatedAt: utcNow,
+				UpdatedAt: utcNow,
 			})
 		}
 
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum < participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortAscending(participants)
 
 	return participants, nil
 }
@@ -1,37 +1,25 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"sort"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByPartyGovernanceVotedList",
+		nil,
+		[]string{"voted"},
+		(*Service).sortByPartyGovernanceVotedList,
+	))
+}
+
 func (s *Service) sortByPartyGovernanceVotedList(socials map[string]verifier.Social) ([]Participant, error) {
-	gqlQuery := `{
-		partiesConnection {
-		  edges {
-			node {
-			  id
-			  votesConnection {
-				edges {
-				  node {
-					proposalId
-					vote {
-					  value
-					  datetime
-					}
-				  }
-				}
-			  }
-			}
-		  }
-		}
-	  }`
-	ctx := context.Background()
-	parties, err := getParties(ctx, s.cfg.VegaGraphQLURL.String(), gqlQuery, nil, nil)
+	ctx := s.ctx
+	parties, err := s.fetchParties(ctx, socialKeys(socials), "votes")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
@@ -47,22 +35,25 @@ func (s *Service) sortByPartyGovernanceVotedList(socials map[string]verifier.Soc
 		}
 
 		if voteCount > 0 {
+			breakdown := s.competitionBreakdown(&party)
+			breakdown.addTerm("votes", decimal.NewFromInt(int64(voteCount)), false)
+			breakdown.Notes = append(breakdown.Notes, "listed for a vote in the competition")
+			breakdown.Score = decimal.NewFromInt(int64(voteCount)).String()
+
 			utcNow := time.Now().UTC()
 			participants = append(participants, Participant{
-				PublicKey:     party.ID,
-				Data:          []string{"Voted"},
-				CreatedAt:     utcNow,
-				UpdatedAt:     utcNow,
-				isBlacklisted: party.blacklisted,
+				PublicKey: party.ID,
+				Data:      []string{"Voted"},
+				Metrics:   Metrics{flagMetric("voted")},
+				breakdown: breakdown,
+				CreatedAt: utcNow,
+				UpdatedAt: utcNow,
 			})
 		}
 
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum < participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortAscending(participants)
 
 	return participants, nil
 }
@@ -1,37 +1,25 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"sort"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByPartyGovernanceVotes",
+		nil,
+		[]string{"votes"},
+		(*Service).sortByPartyGovernanceVotes,
+	))
+}
+
 func (s *Service) sortByPartyGovernanceVotes(socials map[string]verifier.Social) ([]Participant, error) {
-	gqlQuery := `{
-		partiesConnection {
-		  edges {
-			node {
-			  id
-			  votesConnection {
-				edges {
-				  node {
-					proposalId
-					vote {
-					  value
-					  datetime
-					}
-				  }
-				}
-			  }
-			}
-		  }
-		}
-	  }`
-	ctx := context.Background()
-	parties, err := getParties(ctx, s.cfg.VegaGraphQLURL.String(), gqlQuery, nil, nil)
+	ctx := s.ctx
+	parties, err := s.fetchParties(ctx, socialKeys(socials), "votes")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
@@ -45,21 +33,23 @@ func (s *Service) sortByPartyGovernanceVotes(socials map[string]verifier.Social)
 				voteCount++
 			}
 		}
+		breakdown := s.competitionBreakdown(&party)
+		breakdown.addTerm("votes", decimal.NewFromInt(int64(voteCount)), false)
+		breakdown.Score = decimal.NewFromInt(int64(voteCount)).String()
+
 		utcNow := time.Now().UTC()
 		participants = append(participants, Participant{
-			PublicKey:     party.ID,
-			Data:          []string{fmt.Sprintf("%d", voteCount)},
-			sortNum:       float64(voteCount),
-			CreatedAt:     utcNow,
-			UpdatedAt:     utcNow,
-			isBlacklisted: party.blacklisted,
+			PublicKey: party.ID,
+			Data:      []string{fmt.Sprintf("%d", voteCount)},
+			Metrics:   Metrics{countMetric("votes", int64(voteCount), "votes")},
+			breakdown: breakdown,
+			sortNum:   decimal.NewFromInt(int64(voteCount)),
+			CreatedAt: utcNow,
+			UpdatedAt: utcNow,
 		})
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum > participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortDescending(participants)
 
 	return participants, nil
 }
@@ -1,64 +1,29 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"sort"
 	"time"
 
+	"github.com/shopspring/decimal"
 	log "github.com/sirupsen/logrus"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByLPCommittedList",
+		[]string{"marketID"},
+		[]string{"providedLiquidity"},
+		(*Service).sortByLPCommittedList,
+	))
+}
+
 func (s *Service) sortByLPCommittedList(socials map[string]verifier.Social) ([]Participant, error) {
 	// Grab the market ID for the market we're targeting
 	marketID, err := s.getAlgorithmConfig("marketID")
 
-	gqlQueryPartiesAccounts := `{
-		partiesConnection {
-		  edges {
-			node {
-			  id
-			  liquidityProvisionsConnection {
-				edges {
-				  node {
-					id
-					market {
-					  id
-					}
-					commitmentAmount
-					createdAt
-					reference
-					buys {
-					  liquidityOrder {
-						reference
-						proportion
-						offset
-					  }
-					}
-					sells {
-					  liquidityOrder {
-						reference
-						proportion
-						offset
-					  }
-					}
-				  }
-				}
-			  }
-			}
-		  }
-		}
-	  }`
-
-	ctx := context.Background()
-	parties, err := getParties(
-		ctx,
-		s.cfg.VegaGraphQLURL.String(),
-		gqlQueryPartiesAccounts,
-		map[string]string{"assetId": s.cfg.VegaAssets[0]},
-		nil,
-	)
+	ctx := s.ctx
+	parties, err := s.fetchParties(ctx, socialKeys(socials), "liquidityProvisions")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
@@ -80,23 +45,27 @@ func (s *Service) sortByLPCommittedList(socials map[string]verifier.Social) ([]P
 		}
 
 		if lpCount > 0 {
+			breakdown := s.competitionBreakdown(&party)
+			countLiquidity(breakdown.LiquidityProvisions, marketID)
+			breakdown.addTerm("lpCommitments", decimal.NewFromInt(int64(lpCount)), false)
+			breakdown.Notes = append(breakdown.Notes, "listed for a liquidity commitment on market "+marketID)
+			breakdown.Score = decimal.NewFromInt(int64(lpCount)).String()
+
 			utcNow := time.Now().UTC()
 			participants = append(participants, Participant{
-				PublicKey:     party.ID,
-				Data:          []string{"Provided Liquidity"},
-				CreatedAt:     utcNow,
-				UpdatedAt:     utcNow,
-				isBlacklisted: party.blacklisted,
+				PublicKey: party.ID,
+				Data:      []string{"Provided Liquidity"},
+				Metrics:   Metrics{flagMetric("providedLiquidity")},
+				breakdown: breakdown,
+				CreatedAt: utcNow,
+				UpdatedAt: utcNow,
 			})
 			break
 		}
 
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum < participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortAscending(participants)
 
 	return participants, nil
 }
@@ -1,58 +1,31 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"math"
-	"sort"
-	"strconv"
 	"time"
 
+	"github.com/shopspring/decimal"
+
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByLPFees",
+		nil,
+		[]string{"lpFees"},
+		(*Service).sortByLPFees,
+	))
+}
+
 func (s *Service) sortByLPFees(socials map[string]verifier.Social) ([]Participant, error) {
-	decimalPlacesStr, err := s.getAlgorithmConfig("decimalPlaces")
+	asset, err := s.settlementAsset()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
-	}
-	decimalPlaces, err := strconv.ParseFloat(decimalPlacesStr, 64)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
+		return nil, err
 	}
 
-	gqlQueryPartiesAccounts := `{
-		partiesConnection {
-		  edges {
-			node {
-			  id
-			  liquidityProvisionsConnection {
-				edges {
-				  node {
-					id
-					market {
-					  id
-					}
-					fee
-					commitmentAmount
-					createdAt
-					reference
-				  }
-				}
-			  }
-			}
-		  }
-		}
-	  }`
-
-	ctx := context.Background()
-	parties, err := getParties(
-		ctx,
-		s.cfg.VegaGraphQLURL.String(),
-		gqlQueryPartiesAccounts,
-		map[string]string{"assetId": s.cfg.VegaAssets[0]},
-		nil,
-	)
+	ctx := s.ctx
+	parties, err := s.fetchParties(ctx, socialKeys(socials), "liquidityProvisions")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
@@ -62,14 +35,15 @@ func (s *Service) sortByLPFees(socials map[string]verifier.Social) ([]Participan
 
 	participants := []Participant{}
 	for _, party := range sParties {
-		lpFees := 0.0
+		lpFees := decimal.Zero
 		// Check for matching parties who have committed LP :)
 		if party.LPsConnection.Edges != nil && len(party.LPsConnection.Edges) > 0 {
 			for _, lpEdge := range party.LPsConnection.Edges {
 				for _, marketID := range s.cfg.MarketIDs {
 					if lpEdge.LP.Market.ID == marketID {
-						if u, err := strconv.ParseFloat(lpEdge.LP.Fee, 32); err == nil {
-							lpFees = u
+						lpFees, err = parseAmount(lpEdge.LP.Fee)
+						if err != nil {
+							return nil, err
 						}
 					}
 				}
@@ -77,31 +51,31 @@ func (s *Service) sortByLPFees(socials map[string]verifier.Social) ([]Participan
 			}
 		}
 
-		if lpFees > 0 {
+		if lpFees.IsPositive() {
 			t := time.Now().UTC()
-			dataFormatted := ""
+			total := lpFees.Shift(-int32(asset.Decimals))
+			dataFormatted := total.StringFixed(percentPlaces)
 
-			dpMultiplier := math.Pow(10, decimalPlaces)
-			total := lpFees / dpMultiplier
-			dataFormatted = strconv.FormatFloat(total, 'f', 10, 32)
+			breakdown := s.competitionBreakdown(&party, asset)
+			breakdown.addTerm("lpFees", total, false)
+			breakdown.Notes = append(breakdown.Notes, "the fee of the last liquidity commitment on a configured market is counted")
+			breakdown.Score = total.String()
 
 			participants = append(participants, Participant{
-				PublicKey:     party.ID,
-				Data:          []string{dataFormatted},
-				sortNum:       lpFees,
-				CreatedAt:     t,
-				UpdatedAt:     t,
-				isBlacklisted: party.blacklisted,
+				PublicKey: party.ID,
+				Data:      []string{dataFormatted},
+				Metrics:   Metrics{amountMetric("lpFees", total, asset)},
+				breakdown: breakdown,
+				sortNum:   lpFees,
+				CreatedAt: t,
+				UpdatedAt: t,
 			})
 		}
 		break
 
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum < participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortAscending(participants)
 
 	return participants, nil
 }
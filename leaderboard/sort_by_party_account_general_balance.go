@@ -1,54 +1,33 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"sort"
-	"strconv"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByPartyAccountGeneralBalance",
+		nil,
+		[]string{"balance"},
+		(*Service).sortByPartyAccountGeneralBalance,
+	))
+}
+
 func (s *Service) sortByPartyAccountGeneralBalance(socials map[string]verifier.Social) ([]Participant, error) {
 	// marketID, err := s.getAlgorithmConfig("marketID")
 	// if err != nil {
 	// 	return nil, fmt.Errorf("failed to get algorithm config: %w", err)
 	// }
 
-	// Grab the DP we're targeting (for the asset we're interested in for the market specified
-	decimalPlacesStr, err := s.getAlgorithmConfig("decimalPlaces")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
-	}
-	decimalPlaces, err := strconv.ParseInt(decimalPlacesStr, 0, 32)
+	asset, err := s.settlementAsset()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
+		return nil, err
 	}
 
-	gqlQueryPartiesAccounts := `query ($assetId: ID) {
-		partiesConnection {
-		  edges {
-			node {
-			  id
-			  accountsConnection(assetId: $assetId) {
-				edges {
-				  node {
-					asset {
-					  id
-					  symbol
-					  decimals
-					}
-					balance
-					type
-				  }
-				}
-			  }
-			}
-		  }
-		}
-	  }`
 	// gqlQueryPartiesTrades := `query($marketId: ID!, $partyId: ID!) {
 	// 	parties(id: $partyId) {
 	// 		trades(marketId: $marketId, first: 1, last: 2) {
@@ -58,15 +37,9 @@ func (s *Service) sortByPartyAccountGeneralBalance(socials map[string]verifier.S
 	// 	}
 	// }`
 
-	ctx := context.Background()
+	ctx := s.ctx
 
-	parties, err := getParties(
-		ctx,
-		s.cfg.VegaGraphQLURL.String(),
-		gqlQueryPartiesAccounts,
-		map[string]string{"assetId": s.cfg.VegaAssets[0]},
-		nil,
-	)
+	parties, err := s.fetchParties(ctx, socialKeys(socials), "accounts")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
@@ -105,36 +78,39 @@ func (s *Service) sortByPartyAccountGeneralBalance(socials map[string]verifier.S
 		// 	}
 		// }
 
-		balanceGeneral := party.Balance(s.cfg.VegaAssets[0], int(decimalPlaces), "ACCOUNT_TYPE_GENERAL", "ACCOUNT_TYPE_MARGIN")
-		var sortNum float64
+		balanceGeneral, err := party.Balance(asset, "ACCOUNT_TYPE_GENERAL", "ACCOUNT_TYPE_MARGIN")
+		if err != nil {
+			return nil, err
+		}
 		// var balanceGeneralStr string
 		// if tradeCount > 0 {
 		// if len(party.Trades) > 0 {
-		balanceGeneralStr := strconv.FormatFloat(balanceGeneral, 'f', int(decimalPlaces), 32)
-		sortNum = balanceGeneral
+		balanceGeneralStr := formatAmount(balanceGeneral, asset)
+		sortNum := balanceGeneral
 		// } else {
 		// 	// Untraded folks have not participated in the competition.
 		// 	balanceGeneralStr = "n/a"
 		// 	sortNum = -1.0e20
 		// }
-		if balanceGeneral > 0 {
+		if balanceGeneral.IsPositive() {
 			utcNow := time.Now().UTC()
+			breakdown := s.balanceBreakdown(&party, asset, balanceGeneral)
+			breakdown.Score = balanceGeneral.String()
+
 			participants = append(participants, Participant{
-				PublicKey:     party.ID,
-				Data:          []string{balanceGeneralStr},
-				sortNum:       sortNum,
-				CreatedAt:     utcNow,
-				UpdatedAt:     utcNow,
-				isBlacklisted: party.blacklisted,
+				PublicKey: party.ID,
+				Data:      []string{balanceGeneralStr},
+				Metrics:   Metrics{amountMetric("balance", balanceGeneral, asset)},
+				breakdown: breakdown,
+				sortNum:   sortNum,
+				CreatedAt: utcNow,
+				UpdatedAt: utcNow,
 			})
 		}
 
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum > participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortDescending(participants)
 
 	return participants, nil
 }
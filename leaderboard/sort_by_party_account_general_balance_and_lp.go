@@ -1,85 +1,35 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"sort"
-	"strconv"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByPartyAccountGeneralBalanceLP",
+		[]string{"marketID"},
+		[]string{"balance"},
+		(*Service).sortByPartyAccountGeneralBalanceAndLP,
+	))
+}
+
 func (s *Service) sortByPartyAccountGeneralBalanceAndLP(socials map[string]verifier.Social) ([]Participant, error) {
 	// Grab the market ID for the market we're targeting
 	marketID, err := s.getAlgorithmConfig("marketID")
-	decimalPlacesStr, err := s.getAlgorithmConfig("decimalPlaces")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
 	}
-	// Grab the DP we're targeting (for the asset we're interested in for the market specified
-	decimalPlaces, err := strconv.ParseInt(decimalPlacesStr, 0, 32)
+	asset, err := s.settlementAsset()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
+		return nil, err
 	}
 
-	gqlQueryPartiesAccounts := `query ($assetId: ID) {
-		partiesConnection {
-		  edges {
-			node {
-			  id
-			  accountsConnection(assetId: $assetId) {
-				edges {
-				  node {
-					asset {
-					  symbol
-					}
-					balance
-					type
-				  }
-				}
-			  }
-			  liquidityProvisionsConnection {
-				edges {
-				  node {
-					id
-					market {
-					  id
-					}
-					commitmentAmount
-					createdAt
-					reference
-					buys {
-					  liquidityOrder {
-						reference
-						proportion
-						offset
-					  }
-					}
-					sells {
-					  liquidityOrder {
-						reference
-						proportion
-						offset
-					  }
-					}
-				  }
-				}
-			  }
-			}
-		  }
-		}
-	  }`
-
-	ctx := context.Background()
-	parties, err := getParties(
-		ctx,
-		s.cfg.VegaGraphQLURL.String(),
-		gqlQueryPartiesAccounts,
-		map[string]string{"assetId": s.cfg.VegaAssets[0]},
-		nil,
-	)
+	ctx := s.ctx
+	parties, err := s.fetchParties(ctx, socialKeys(socials), "accounts", "liquidityProvisions")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
@@ -95,20 +45,27 @@ func (s *Service) sortByPartyAccountGeneralBalanceAndLP(socials map[string]verif
 				if lpEdge.LP.Market.ID == marketID {
 					log.WithFields(log.Fields{"partyID": party.ID, "totalLPs": len(party.LPsConnection.Edges)}).Info("Party has LPs on correct market")
 
-					balanceGeneral := party.Balance(s.cfg.VegaAssets[0], int(decimalPlaces), "ACCOUNT_TYPE_GENERAL", "ACCOUNT_TYPE_MARGIN")
-					var sortNum float64
+					balanceGeneral, err := party.Balance(asset, "ACCOUNT_TYPE_GENERAL", "ACCOUNT_TYPE_MARGIN")
+					if err != nil {
+						return nil, err
+					}
+					balanceGeneralStr := formatAmount(balanceGeneral, asset)
+					sortNum := balanceGeneral
 
-					balanceGeneralStr := strconv.FormatFloat(balanceGeneral, 'f', int(decimalPlaces), 32)
-					sortNum = balanceGeneral
+					breakdown := s.balanceBreakdown(&party, asset, balanceGeneral)
+					countLiquidity(breakdown.LiquidityProvisions, marketID)
+					breakdown.Notes = append(breakdown.Notes, "ranked for a liquidity commitment on market "+marketID)
+					breakdown.Score = balanceGeneral.String()
 
 					utcNow := time.Now().UTC()
 					participants = append(participants, Participant{
-						PublicKey:     party.ID,
-						Data:          []string{balanceGeneralStr},
-						sortNum:       sortNum,
-						CreatedAt:     utcNow,
-						UpdatedAt:     utcNow,
-						isBlacklisted: party.blacklisted,
+						PublicKey: party.ID,
+						Data:      []string{balanceGeneralStr},
+						Metrics:   Metrics{amountMetric("balance", balanceGeneral, asset)},
+						breakdown: breakdown,
+						sortNum:   sortNum,
+						CreatedAt: utcNow,
+						UpdatedAt: utcNow,
 					})
 					break
 				}
@@ -116,10 +73,7 @@ func (s *Service) sortByPartyAccountGeneralBalanceAndLP(socials map[string]verif
 		}
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum > participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortDescending(participants)
 
 	return participants, nil
 }
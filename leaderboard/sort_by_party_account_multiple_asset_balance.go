@@ -1,87 +1,81 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"sort"
-	"strconv"
 	"time"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/shopspring/decimal"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
-func (s *Service) sortByPartyAccountMultipleBalance(socials map[string]verifier.Social) ([]Participant, error) {
-	// Query all accounts for parties on Vega network
-	gqlQueryPartiesAccounts := `query(){
-		partiesConnection {
-		  edges {
-			node {
-			  id
-			  accountsConnection {
-				edges {
-				  node {
-					asset {
-					  id
-					  symbol
-					  decimals
-					}
-					balance
-					type
-				  }
-				}
-			  }
-			}
-		  }
-	  }
-	}`
-	ctx := context.Background()
-	parties, err := getParties(
-		ctx,
-		s.cfg.VegaGraphQLURL.String(),
-		gqlQueryPartiesAccounts,
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByPartyAccountMultipleBalance",
 		nil,
-		nil,
-	)
+		[]string{"balance"},
+		(*Service).sortByPartyAccountMultipleBalance,
+	))
+}
+
+func (s *Service) sortByPartyAccountMultipleBalance(socials map[string]verifier.Social) ([]Participant, error) {
+	ctx := s.ctx
+	parties, err := s.fetchParties(ctx, socialKeys(socials), "accounts")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
 
+	// Balances are summed in whole units and shown with the most decimals of any asset
+	assets := make([]Asset, 0, len(s.cfg.VegaAssets))
+	widest := Asset{}
+	for _, id := range s.cfg.VegaAssets {
+		asset, err := s.asset(id)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, asset)
+		if asset.Decimals > widest.Decimals {
+			widest = asset
+		}
+	}
+
 	// filter parties and add social handles
 	sParties := socialParties(socials, parties)
 	participants := []Participant{}
 	for _, party := range sParties {
-		balanceMultiAsset := 0.0
+		balanceMultiAsset := decimal.Zero
 		for _, acc := range party.AccountsConnection.Edges {
-			for _, asset := range s.cfg.VegaAssets {
-				if acc.Account.Asset.Id == asset {
-					b := party.Balance(acc.Account.Asset.Id, acc.Account.Asset.Decimals, acc.Account.Type)
-					balanceMultiAsset += b
+			for _, asset := range assets {
+				if acc.Account.Asset.Id == asset.Id {
+					amount, err := assetAmount(acc.Account.Balance, asset)
+					if err != nil {
+						return nil, err
+					}
+					balanceMultiAsset = balanceMultiAsset.Add(amount)
 				}
 			}
 		}
 
-		if balanceMultiAsset > 0.0 {
-			if party.blacklisted {
-				log.Infof("Blacklisted party added: %d, %s, %s", party.twitterID, party.social, party.ID)
-			}
+		if balanceMultiAsset.IsPositive() {
+			// Every account of the assets counts, whatever its type
+			breakdown := s.competitionBreakdown(&party, assets...)
+			breakdown.addTerm("balance", balanceMultiAsset, false)
+			breakdown.Score = balanceMultiAsset.String()
 
 			t := time.Now().UTC()
 			participants = append(participants, Participant{
-				PublicKey:     party.ID,
-				Data:          []string{strconv.FormatFloat(balanceMultiAsset, 'f', 10, 32)},
-				sortNum:       balanceMultiAsset,
-				CreatedAt:     t,
-				UpdatedAt:     t,
-				isBlacklisted: party.blacklisted,
+				PublicKey: party.ID,
+				Data:      []string{formatAmount(balanceMultiAsset, widest)},
+				// The sum of several assets, so without an asset symbol
+				Metrics:   Metrics{decimalMetric("balance", balanceMultiAsset, int32(widest.Decimals))},
+				breakdown: breakdown,
+				sortNum:   balanceMultiAsset,
+				CreatedAt: t,
+				UpdatedAt: t,
 			})
 		}
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum > participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortDescending(participants)
 
 	return participants, nil
 }
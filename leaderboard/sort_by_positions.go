@@ -1,56 +1,72 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"math"
-	"sort"
-	"strconv"
 	"time"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/shopspring/decimal"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
-func (s *Service) sortByPartyPositions(socials map[string]verifier.Social) ([]Participant, error) {
-	// Grab the DP we're targeting (for the asset we're interested in for the market specified
-	decimalPlacesStr, err := s.getAlgorithmConfig("decimalPlaces")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
+// Query all accounts for parties on Vega network
+var gqlQueryPositionsParties string = `query ($marketId: [ID!], $partyIds: [ID!], $pagination: Pagination){
+	positions (filter: {marketIds: $marketId, partyIds: $partyIds}, pagination: $pagination) {
+		edges {
+		node {
+			market {
+			id
+			}
+			party {
+			id
+			}
+			openVolume
+			realisedPNL
+			averageEntryPrice
+			unrealisedPNL
+			realisedPNL
+		}
+		}
+		pageInfo {
+		hasNextPage
+		endCursor
+		}
 	}
-	decimalPlaces, err := strconv.ParseFloat(decimalPlacesStr, 64)
+	}`
+
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByPartyPositions",
+		nil,
+		[]string{"pnl"},
+		(*Service).sortByPartyPositions,
+	))
+}
+
+func (s *Service) sortByPartyPositions(socials map[string]verifier.Social) ([]Participant, error) {
+	asset, err := s.settlementAsset()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
+		return nil, err
 	}
 
-	// Query all accounts for parties on Vega network
-	gqlQueryPositionsParties := `query ($marketId: [ID!]){
-		positions (filter: {marketIds: $marketId}) {
-			edges {
-			node {
-				market {
-				id
-				}
-				party {
-				id
-				}
-				openVolume
-				realisedPNL
-				averageEntryPrice
-				unrealisedPNL
-				realisedPNL
-			}
-			}
+	ctx := s.ctx
+	positions := []PositionsEdge{}
+	err = forEachPage(Pagination{First: pageSize}, func(pagination Pagination) (PageInfo, error) {
+		connection, err := getPositions(
+			ctx,
+			s.dataNode,
+			gqlQueryPositionsParties,
+			map[string]interface{}{
+				"marketId":   s.cfg.MarketIDs[0],
+				"partyIds":   socialKeys(socials),
+				"pagination": pagination,
+			},
+		)
+		if err != nil {
+			return PageInfo{}, err
 		}
-		}`
-	ctx := context.Background()
-	positions, err := getPositions(
-		ctx,
-		s.cfg.VegaGraphQLURL.String(),
-		gqlQueryPositionsParties,
-		map[string]string{"marketId": s.cfg.MarketIDs[0]},
-		nil,
-	)
+		positions = append(positions, connection.Edges...)
+		return connection.PageInfo, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of positions: %w", err)
 	}
@@ -59,53 +75,49 @@ func (s *Service) sortByPartyPositions(socials map[string]verifier.Social) ([]Pa
 	sPositions := socialPositions(socials, positions)
 	participants := []Participant{}
 	for _, position := range sPositions {
-		PnL := 0.0
-		realisedPnL := 0.0
-		unrealisedPnL := 0.0
-		openVolume := 0.0
+		PnL := decimal.Zero
+		realisedPnL := decimal.Zero
+		unrealisedPnL := decimal.Zero
+		openVolume := decimal.Zero
 		if err == nil {
 			for _, marketID := range s.cfg.MarketIDs {
 				if position.Market.ID == marketID {
-					if s, err := strconv.ParseFloat(position.RealisedPNL, 32); err == nil {
-						realisedPnL += s
+					realised, unrealised, volume, err := position.amounts(asset)
+					if err != nil {
+						return nil, err
 					}
-					if t, err := strconv.ParseFloat(position.UnrealisedPNL, 32); err == nil {
-						unrealisedPnL += t
-					}
-					if u, err := strconv.ParseFloat(position.OpenVolume, 32); err == nil {
-						openVolume += u
-					}
-					PnL += realisedPnL + unrealisedPnL
+					realisedPnL = realisedPnL.Add(realised)
+					unrealisedPnL = unrealisedPnL.Add(unrealised)
+					openVolume = openVolume.Add(volume)
+					PnL = realisedPnL.Add(unrealisedPnL)
 				}
 			}
 		}
 
-		if (realisedPnL != 0.0) || (unrealisedPnL != 0.0) || (openVolume != 0.0) {
-			if position.Party.blacklisted {
-				log.Infof("Blacklisted party added: %d, %s, %s", position.PartytwitterID, position.Partysocial, position.PartyID)
-			}
+		if !realisedPnL.IsZero() || !unrealisedPnL.IsZero() || !openVolume.IsZero() {
 			t := time.Now().UTC()
 			dataFormatted := ""
-			if PnL != 0 {
-				dpMultiplier := math.Pow(10, decimalPlaces)
-				total := PnL / dpMultiplier
-				dataFormatted = strconv.FormatFloat(total, 'f', 10, 32)
+			if !PnL.IsZero() {
+				dataFormatted = formatAmount(PnL, asset)
 			}
+			party := Party{ID: position.Party.ID}
+			party.PositionsConnection.Edges = []PositionsEdge{{Position: position}}
+			breakdown := s.positionsBreakdown(&party, asset, realisedPnL, unrealisedPnL)
+			breakdown.Score = PnL.String()
+
 			participants = append(participants, Participant{
-				PublicKey:     position.Party.ID,
-				Data:          []string{dataFormatted},
-				sortNum:       PnL,
-				CreatedAt:     t,
-				UpdatedAt:     t,
-				isBlacklisted: position.Party.blacklisted,
+				PublicKey: position.Party.ID,
+				Data:      []string{dataFormatted},
+				Metrics:   Metrics{amountMetric("pnl", PnL, asset)},
+				breakdown: breakdown,
+				sortNum:   PnL,
+				CreatedAt: t,
+				UpdatedAt: t,
 			})
 		}
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum > participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortDescending(participants)
 
 	return participants, nil
 }
@@ -1,63 +1,37 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"math"
-	"sort"
-	"strconv"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
+func init() {
+	RegisterAlgorithm(internalAlgorithm{NewAlgorithm(
+		"ByPartyPositionsInternal",
+		nil,
+		[]string{"pnl"},
+		(*Service).sortByPartyPositionsInternal,
+	)})
+}
+
+// internalAlgorithm ranks the PnL of the excluded parties, e.g. team accounts.
+type internalAlgorithm struct {
+	Algorithm
+}
+
+func (internalAlgorithm) RanksExcluded() bool { return true }
+
 func (s *Service) sortByPartyPositionsInternal(socials map[string]verifier.Social) ([]Participant, error) {
-	// Grab the DP we're targeting (for the asset we're interested in for the market specified
-	decimalPlacesStr, err := s.getAlgorithmConfig("decimalPlaces")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
-	}
-	decimalPlaces, err := strconv.ParseFloat(decimalPlacesStr, 64)
+	asset, err := s.settlementAsset()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
+		return nil, err
 	}
 
-	// Query all accounts for parties on Vega network
-	gqlQueryPartiesAccounts := `{
-		partiesConnection (pagination: {first: 500}) {
-	      edges {
-	        node {
-	          id
-	          positionsConnection {
-	            edges {
-	              node {
-	              market{id}
-	              openVolume
-	              realisedPNL
-	              averageEntryPrice
-	              unrealisedPNL
-	              realisedPNL
-	              }
-	            }
-              pageInfo {
-                hasNextPage
-                hasPreviousPage
-                startCursor
-                endCursor
-              }
-			  }
-			}
-		  }
-	    }
-	}`
-	ctx := context.Background()
-	parties, err := getParties(
-		ctx,
-		s.cfg.VegaGraphQLURL.String(),
-		gqlQueryPartiesAccounts,
-		nil,
-		nil,
-	)
+	ctx := s.ctx
+	parties, err := s.fetchParties(ctx, socialKeys(socials), "positions")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
@@ -66,53 +40,49 @@ func (s *Service) sortByPartyPositionsInternal(socials map[string]verifier.Socia
 	sParties := socialParties(socials, parties)
 	participants := []Participant{}
 	for _, party := range sParties {
-		PnL := 0.0
-		realisedPnL := 0.0
-		unrealisedPnL := 0.0
-		openVolume := 0.0
+		PnL := decimal.Zero
+		realisedPnL := decimal.Zero
+		unrealisedPnL := decimal.Zero
+		openVolume := decimal.Zero
 		if err == nil {
 			for _, acc := range party.PositionsConnection.Edges {
 				for _, marketID := range s.cfg.MarketIDs {
 					if acc.Position.Market.ID == marketID {
-						if s, err := strconv.ParseFloat(acc.Position.RealisedPNL, 32); err == nil {
-							realisedPnL += s
-						}
-						if t, err := strconv.ParseFloat(acc.Position.UnrealisedPNL, 32); err == nil {
-							unrealisedPnL += t
+						realised, unrealised, volume, err := acc.Position.amounts(asset)
+						if err != nil {
+							return nil, err
 						}
-						if u, err := strconv.ParseFloat(acc.Position.OpenVolume, 32); err == nil {
-							openVolume += u
-						}
-						PnL = realisedPnL + unrealisedPnL
+						realisedPnL = realisedPnL.Add(realised)
+						unrealisedPnL = unrealisedPnL.Add(unrealised)
+						openVolume = openVolume.Add(volume)
+						PnL = realisedPnL.Add(unrealisedPnL)
 					}
 				}
 			}
 		}
 
-		if (realisedPnL != 0.0) || (unrealisedPnL != 0.0) || (openVolume != 0.0) {
-			if party.blacklisted {
-				t := time.Now().UTC()
-				dataFormatted := ""
-				if PnL != 0 {
-					dpMultiplier := math.Pow(10, decimalPlaces)
-					total := PnL / dpMultiplier
-					dataFormatted = strconv.FormatFloat(total, 'f', 10, 32)
-				}
-				participants = append(participants, Participant{
-					PublicKey: party.ID,
-					Data:      []string{dataFormatted},
-					sortNum:   PnL,
-					CreatedAt: t,
-					UpdatedAt: t,
-				})
+		if !realisedPnL.IsZero() || !unrealisedPnL.IsZero() || !openVolume.IsZero() {
+			t := time.Now().UTC()
+			dataFormatted := ""
+			if !PnL.IsZero() {
+				dataFormatted = formatAmount(PnL, asset)
 			}
+			breakdown := s.positionsBreakdown(&party, asset, realisedPnL, unrealisedPnL)
+			breakdown.Score = PnL.String()
+
+			participants = append(participants, Participant{
+				PublicKey: party.ID,
+				Data:      []string{dataFormatted},
+				Metrics:   Metrics{amountMetric("pnl", PnL, asset)},
+				breakdown: breakdown,
+				sortNum:   PnL,
+				CreatedAt: t,
+				UpdatedAt: t,
+			})
 		}
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum > participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortDescending(participants)
 
 	return participants, nil
 }
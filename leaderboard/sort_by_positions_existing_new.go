@@ -1,87 +1,37 @@
 package leaderboard
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"math"
-	"os"
-	"sort"
-	"strconv"
 	"time"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/shopspring/decimal"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByPartyPositionsExistingNew",
+		[]string{"baseline"},
+		[]string{"pnl"},
+		(*Service).sortByPartyPositionsExistingNew,
+	))
+}
+
 func (s *Service) sortByPartyPositionsExistingNew(socials map[string]verifier.Social) ([]Participant, error) {
 
-	// Grab the DP we're targeting (for the asset we're interested in for the market specified
-	decimalPlacesStr, err := s.getAlgorithmConfig("decimalPlaces")
+	asset, err := s.settlementAsset()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
-	}
-	decimalPlaces, err := strconv.ParseFloat(decimalPlacesStr, 64)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
+		return nil, err
 	}
 
-	// Open our jsonFile
-	jsonFile, err := os.Open("/data/initial_results.json")
-	// if we os.Open returns an error then handle it
+	// Scores at the start of the competition, e.g. the results of a previous round
+	alreadyTraded, err := s.baselineScoresFromConfig("baseline")
 	if err != nil {
-		fmt.Println(err)
+		return nil, err
 	}
 
-	// read our opened jsonFile as a byte array.
-	byteValue, _ := ioutil.ReadAll(jsonFile)
-
-	alreadyTraded := []Participant{}
-
-	// we unmarshal our byteArray which contains our
-	// jsonFile's content into 'alreadyTraded' which we defined above
-	json.Unmarshal(byteValue, &alreadyTraded)
-
-	// defer the closing of our jsonFile so that we can parse it later on
-	defer jsonFile.Close()
-
-	// Query all accounts for parties on Vega network
-	gqlQueryPartiesAccounts := `{
-		partiesConnection (pagination: {first: 500}) {
-	      edges {
-	        node {
-	          id
-	          positionsConnection {
-	            edges {
-	              node {
-	              market{id}
-	              openVolume
-	              realisedPNL
-	              averageEntryPrice
-	              unrealisedPNL
-	              realisedPNL
-	              }
-	            }
-              pageInfo {
-                hasNextPage
-                hasPreviousPage
-                startCursor
-                endCursor
-              }
-			  }
-			}
-		  }
-	    }
-	}`
-	ctx := context.Background()
-	parties, err := getParties(
-		ctx,
-		s.cfg.VegaGraphQLURL.String(),
-		gqlQueryPartiesAccounts,
-		nil,
-		nil,
-	)
+	ctx := s.ctx
+	parties, err := s.fetchParties(ctx, socialKeys(socials), "positions")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
@@ -91,69 +41,63 @@ func (s *Service) sortByPartyPositionsExistingNew(socials map[string]verifier.So
 	participants := []Participant{}
 	// if participant in JSON, PNL = json data, otherwise starting PnL 0
 	for _, party := range sParties {
-		PnL := 0.0
-		realisedPnL := 0.0
-		unrealisedPnL := 0.0
-		openVolume := 0.0
-		percentagePnL := 0.0
+		PnL := decimal.Zero
+		realisedPnL := decimal.Zero
+		unrealisedPnL := decimal.Zero
+		openVolume := decimal.Zero
+		percentagePnL := decimal.Zero
 		dataFormatted := ""
-		dpMultiplier := math.Pow(10, decimalPlaces)
+		startingBalance := decimal.NewFromInt(10500)
 		if err == nil {
 			for _, acc := range party.PositionsConnection.Edges {
 				for _, marketID := range s.cfg.MarketIDs {
 					if acc.Position.Market.ID == marketID {
-						if s, err := strconv.ParseFloat(acc.Position.RealisedPNL, 32); err == nil {
-							realisedPnL += s
+						realised, unrealised, volume, err := acc.Position.amounts(asset)
+						if err != nil {
+							return nil, err
 						}
-						if t, err := strconv.ParseFloat(acc.Position.UnrealisedPNL, 32); err == nil {
-							unrealisedPnL += t
-						}
-						if u, err := strconv.ParseFloat(acc.Position.OpenVolume, 32); err == nil {
-							openVolume += u
-						}
-						PnL = (realisedPnL + unrealisedPnL)
-						percentagePnL = ((PnL / dpMultiplier) / 10500) * 100
-						dataFormatted = strconv.FormatFloat(percentagePnL, 'f', 10, 32)
+						realisedPnL = realisedPnL.Add(realised)
+						unrealisedPnL = unrealisedPnL.Add(unrealised)
+						openVolume = openVolume.Add(volume)
+						PnL = realisedPnL.Add(unrealisedPnL)
+						percentagePnL = PnL.Div(startingBalance).Shift(2)
+						dataFormatted = formatPercent(percentagePnL)
 					}
 				}
 
 			}
 		}
 
-		if (realisedPnL != 0.0) || (unrealisedPnL != 0.0) || (openVolume != 0.0) {
-			if party.blacklisted {
-				log.Infof("Blacklisted party added: %d, %s, %s", party.twitterID, party.social, party.ID)
-			}
-
+		if !realisedPnL.IsZero() || !unrealisedPnL.IsZero() || !openVolume.IsZero() {
 			t := time.Now().UTC()
-			total := 0.0
-			if PnL != 0 {
-				total = PnL / dpMultiplier
-				for _, traded := range alreadyTraded {
-					if traded.PublicKey == party.ID {
-						if s, err := strconv.ParseFloat(traded.Data[0], 32); err == nil {
-							percentagePnL = ((total - s) / (s + 10500)) * 100
-						}
-					}
+			baseline, found := alreadyTraded[party.ID]
+			if !PnL.IsZero() {
+				if found {
+					percentagePnL = PnL.Sub(baseline).Div(baseline.Add(startingBalance)).Shift(2)
 				}
-				dataFormatted = strconv.FormatFloat(percentagePnL, 'f', 10, 32)
+				dataFormatted = formatPercent(percentagePnL)
 			}
 
+			breakdown := s.positionsBreakdown(&party, asset, realisedPnL, unrealisedPnL)
+			breakdown.addTerm("baseline", baseline, true)
+			breakdown.Notes = append(breakdown.Notes, fmt.Sprintf(
+				"the score is the sum of the terms as a percentage of the starting balance of %s plus the baseline",
+				startingBalance))
+			breakdown.Score = percentagePnL.String()
+
 			participants = append(participants, Participant{
-				PublicKey:     party.ID,
-				Data:          []string{dataFormatted},
-				sortNum:       percentagePnL,
-				CreatedAt:     t,
-				UpdatedAt:     t,
-				isBlacklisted: party.blacklisted,
+				PublicKey: party.ID,
+				Data:      []string{dataFormatted},
+				Metrics:   Metrics{percentMetric("pnl", percentagePnL, percentPlaces)},
+				breakdown: breakdown,
+				sortNum:   percentagePnL,
+				CreatedAt: t,
+				UpdatedAt: t,
 			})
 		}
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum > participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortDescending(participants)
 
 	return participants, nil
 }
@@ -1,141 +1,39 @@
 package leaderboard
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"math"
-	"os"
-	"sort"
-	"strconv"
 	"time"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/shopspring/decimal"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
-var gqlQueryPartiesAccountsPercent string = `query ($pagination: Pagination!) {
-	partiesConnection(pagination: $pagination) {
-	  edges {
-		node {
-		  id
-		  positionsConnection {
-			edges {
-			  node {
-				market {
-				  id
-				}
-				openVolume
-				realisedPNL
-				averageEntryPrice
-				unrealisedPNL
-				realisedPNL
-			  }
-			}
-		  }
-		  transfersConnection(direction: To) {
-			edges {
-			  node {
-				id
-				fromAccountType
-				toAccountType
-				from
-				amount
-				timestamp
-				asset {
-				  id
-				  name
-				}
-			  }
-			}
-		  }
-		  depositsConnection {
-			edges {
-			  node {
-				amount
-				createdTimestamp
-				creditedTimestamp
-				status
-				asset {
-				  id
-				  symbol
-				  source {
-					__typename
-				  }
-				}
-			  }
-			}
-		  }
-		}
-	  }
-	  pageInfo {
-		hasNextPage
-		hasPreviousPage
-		startCursor
-		endCursor
-	  }
-	}
-  }`
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByPartyPositionsWithTransfersPercentage",
+		[]string{"baseline"},
+		[]string{"pnl"},
+		(*Service).sortByPartyPositionsWithTransfersPercentage,
+	))
+}
 
 func (s *Service) sortByPartyPositionsWithTransfersPercentage(socials map[string]verifier.Social) ([]Participant, error) {
 
-	// Grab the DP we're targeting (for the asset we're interested in for the market specified
-	decimalPlacesStr, err := s.getAlgorithmConfig("decimalPlaces")
+	asset, err := s.settlementAsset()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
-	}
-	decimalPlaces, err := strconv.ParseFloat(decimalPlacesStr, 64)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
+		return nil, err
 	}
 
-	// Open our jsonFile
-	jsonFile, err := os.Open("/data/initial_results.json")
-	// if we os.Open returns an error then handle it
+	// Scores at the start of the competition, e.g. the results of a previous round
+	alreadyTraded, err := s.baselineScoresFromConfig("baseline")
 	if err != nil {
-		fmt.Println(err)
+		return nil, err
 	}
 
-	// read our opened jsonFile as a byte array.
-	byteValue, _ := ioutil.ReadAll(jsonFile)
-
-	alreadyTraded := []Participant{}
-
-	// we unmarshal our byteArray which contains our
-	// jsonFile's content into 'alreadyTraded' which we defined above
-	json.Unmarshal(byteValue, &alreadyTraded)
-
-	// defer the closing of our jsonFile so that we can parse it later on
-	defer jsonFile.Close()
-
-	pagination := Pagination{First: 50}
-
-	ctx := context.Background()
-	partyEdges := []PartiesEdge{}
-	for {
-		connection, err := getPartiesConnection(
-			ctx,
-			s.cfg.VegaGraphQLURL.String(),
-			gqlQueryPartiesAccountsPercent,
-			map[string]interface{}{"pagination": pagination},
-			nil,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get list of parties in loop: %w", err)
-		}
-
-		partyEdges = append(partyEdges, connection.Edges...)
-
-		// fmt.Println("got ", len(partyEdges), "end?", connection.PageInfo.EndCursor)
-
-		if !connection.PageInfo.NextPage {
-			// fmt.Println("done")
-			break
-		} else {
-			pagination.After = connection.PageInfo.EndCursor
-		}
-
+	ctx := s.ctx
+	partyEdges, err := s.fetchParties(ctx, socialKeys(socials), "deposits", "positions", "transfers")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
 
 	// filter parties and add social handles
@@ -143,96 +41,94 @@ func (s *Service) sortByPartyPositionsWithTransfersPercentage(socials map[string
 	participants := []Participant{}
 	// if participant in JSON, PNL = json data, otherwise starting PnL 0
 	for _, party := range sParties {
-		transfer := 1000.0
-		deposit := 0.0
-		if len(party.TransfersConnection.Edges) != 0 {
-			for _, w := range party.TransfersConnection.Edges {
+		transfer := decimal.New(1000, -int32(asset.Decimals))
+		deposit := decimal.Zero
+		transferID, depositID := "", ""
+		for _, w := range party.TransfersConnection.Edges {
+			if w.Transfer.Asset.Id == s.cfg.VegaAssets[0] &&
+				w.Transfer.Timestamp.After(s.cfg.StartTime) &&
+				w.Transfer.Timestamp.Before(s.cfg.EndTime) {
+				transfer, err = assetAmount(w.Transfer.Amount, asset)
 				if err != nil {
-					fmt.Errorf("failed to convert Transfer amount to string", err)
-				}
-				if w.Transfer.Asset.Id == s.cfg.VegaAssets[0] &&
-					w.Transfer.Timestamp.After(s.cfg.StartTime) &&
-					w.Transfer.Timestamp.Before(s.cfg.EndTime) {
-					transfer, err = strconv.ParseFloat(w.Transfer.Amount, 64)
+					return nil, err
 				}
+				transferID = w.Transfer.Id
 			}
 		}
 
 		for _, d := range party.DepositsConnection.Edges {
-			if err != nil {
-				fmt.Errorf("failed to convert Withdrawal amount to string", err)
-			}
-
 			if d.Deposit.Asset.Id == s.cfg.VegaAssets[0] &&
 				d.Deposit.Status == "STATUS_FINALIZED" &&
 				d.Deposit.CreatedAt.After(s.cfg.StartTime) &&
 				d.Deposit.CreatedAt.Before(s.cfg.EndTime) {
-				deposit, err = strconv.ParseFloat(d.Deposit.Amount, 64)
+				deposit, err = assetAmount(d.Deposit.Amount, asset)
+				if err != nil {
+					return nil, err
+				}
+				depositID = d.Deposit.Id
 			}
 		}
-		PnL := 0.0
-		realisedPnL := 0.0
-		unrealisedPnL := 0.0
-		openVolume := 0.0
-		percentagePnL := 0.0
+		PnL := decimal.Zero
+		realisedPnL := decimal.Zero
+		unrealisedPnL := decimal.Zero
+		openVolume := decimal.Zero
+		percentagePnL := decimal.Zero
 		dataFormatted := ""
-		dpMultiplier := math.Pow(10, decimalPlaces)
+		startingBalance := decimal.NewFromInt(19200)
 		if err == nil {
 			for _, acc := range party.PositionsConnection.Edges {
 				for _, marketID := range s.cfg.MarketIDs {
 					if acc.Position.Market.ID == marketID {
-						if s, err := strconv.ParseFloat(acc.Position.RealisedPNL, 32); err == nil {
-							realisedPnL += s
-						}
-						if t, err := strconv.ParseFloat(acc.Position.UnrealisedPNL, 32); err == nil {
-							unrealisedPnL += t
-						}
-						if u, err := strconv.ParseFloat(acc.Position.OpenVolume, 32); err == nil {
-							openVolume += u
+						realised, unrealised, volume, err := acc.Position.amounts(asset)
+						if err != nil {
+							return nil, err
 						}
-						PnL = (realisedPnL + unrealisedPnL)
-						percentagePnL = (((PnL / dpMultiplier) - (transfer / dpMultiplier) - (deposit / dpMultiplier)) / (19200 + (transfer / dpMultiplier) + (deposit / dpMultiplier))) * 100
-						dataFormatted = strconv.FormatFloat(percentagePnL, 'f', 10, 32)
+						realisedPnL = realisedPnL.Add(realised)
+						unrealisedPnL = unrealisedPnL.Add(unrealised)
+						openVolume = openVolume.Add(volume)
+						PnL = realisedPnL.Add(unrealisedPnL)
+						percentagePnL = PnL.Sub(transfer).Sub(deposit).Div(startingBalance.Add(transfer).Add(deposit)).Shift(2)
+						dataFormatted = formatPercent(percentagePnL)
 					}
 				}
 
 			}
 		}
 
-		if (realisedPnL != 0.0) || (unrealisedPnL != 0.0) || (openVolume != 0.0) {
-			if party.blacklisted {
-				log.Infof("Blacklisted party added: %d, %s, %s", party.twitterID, party.social, party.ID)
-			}
-
+		if !realisedPnL.IsZero() || !unrealisedPnL.IsZero() || !openVolume.IsZero() {
 			t := time.Now().UTC()
-			total := 0.0
-			if PnL != 0 {
-				total = PnL / dpMultiplier
-				for _, traded := range alreadyTraded {
-					if traded.PublicKey == party.ID {
-						if s, err := strconv.ParseFloat(traded.Data[0], 32); err == nil {
-							percentagePnL = ((total - s - (transfer / dpMultiplier) - (deposit / dpMultiplier)) / (s + 19200 + (transfer / dpMultiplier) + (deposit / dpMultiplier))) * 100
-						}
-					}
+			baseline, found := alreadyTraded[party.ID]
+			if !PnL.IsZero() {
+				if found {
+					percentagePnL = PnL.Sub(baseline).Sub(transfer).Sub(deposit).Div(baseline.Add(startingBalance).Add(transfer).Add(deposit)).Shift(2)
 				}
-				dataFormatted = strconv.FormatFloat(percentagePnL, 'f', 10, 32)
+				dataFormatted = formatPercent(percentagePnL)
 			}
 
+			breakdown := s.positionsWithTransfersBreakdown(&party, asset, transferID, depositID)
+			breakdown.addTerm("realisedPnL", realisedPnL, false)
+			breakdown.addTerm("unrealisedPnL", unrealisedPnL, false)
+			breakdown.addTerm("baseline", baseline, true)
+			breakdown.addTerm("transfer", transfer, true)
+			breakdown.addTerm("deposit", deposit, true)
+			breakdown.Notes = append(breakdown.Notes, fmt.Sprintf(
+				"the score is the sum of the terms as a percentage of the starting balance of %s plus the baseline, transfer and deposit",
+				startingBalance))
+			breakdown.Score = percentagePnL.String()
+
 			participants = append(participants, Participant{
-				PublicKey:     party.ID,
-				Data:          []string{dataFormatted},
-				sortNum:       percentagePnL,
-				CreatedAt:     t,
-				UpdatedAt:     t,
-				isBlacklisted: party.blacklisted,
+				PublicKey: party.ID,
+				Data:      []string{dataFormatted},
+				Metrics:   Metrics{percentMetric("pnl", percentagePnL, percentPlaces)},
+				breakdown: breakdown,
+				sortNum:   percentagePnL,
+				CreatedAt: t,
+				UpdatedAt: t,
 			})
 		}
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum > participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortDescending(participants)
 
 	return participants, nil
 }
@@ -1,85 +1,33 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"math"
-	"sort"
-	"strconv"
 	"time"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/shopspring/decimal"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
-var gqlQueryPartiesAccountsMakerReceived string = `query ($pagination: Pagination!) {
-	partiesConnection(pagination: $pagination) {
-	  edges {
-		node {
-		  id
-		  rewardsConnection {
-			edges {
-			  node {
-				amount
-				asset {
-				  id
-				}
-				marketId
-				rewardType
-				receivedAt
-			  }
-			}
-		  }
-		}
-	  }
-	  pageInfo {
-		hasNextPage
-		hasPreviousPage
-		startCursor
-		endCursor
-	  }
-	}
-  }`
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByPartyRewardsMakerReceived",
+		nil,
+		[]string{"rewards"},
+		(*Service).sortByPartyRewardsMakerReceived,
+	))
+}
 
 func (s *Service) sortByPartyRewardsMakerReceived(socials map[string]verifier.Social) ([]Participant, error) {
 
-	// Grab the DP we're targeting (for the asset we're interested in for the market specified
-	decimalPlacesStr, err := s.getAlgorithmConfig("decimalPlaces")
+	asset, err := s.settlementAsset()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
+		return nil, err
 	}
-	decimalPlaces, err := strconv.ParseFloat(decimalPlacesStr, 64)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
-	}
-
-	pagination := Pagination{First: 50}
-
-	ctx := context.Background()
-	partyEdges := []PartiesEdge{}
-	for {
-		connection, err := getPartiesConnection(
-			ctx,
-			s.cfg.VegaGraphQLURL.String(),
-			gqlQueryPartiesAccountsMakerReceived,
-			map[string]interface{}{"pagination": pagination},
-			nil,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get list of parties in loop: %w", err)
-		}
-
-		partyEdges = append(partyEdges, connection.Edges...)
-
-		// fmt.Println("got ", len(partyEdges), "end?", connection.PageInfo.EndCursor)
-
-		if !connection.PageInfo.NextPage {
-			// fmt.Println("done")
-			break
-		} else {
-			pagination.After = connection.PageInfo.EndCursor
-		}
 
+	ctx := s.ctx
+	partyEdges, err := s.fetchParties(ctx, socialKeys(socials), "rewards")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
 
 	// filter parties and add social handles
@@ -87,53 +35,45 @@ func (s *Service) sortByPartyRewardsMakerReceived(socials map[string]verifier.So
 	participants := []Participant{}
 	// if participant in JSON, PNL = json data, otherwise starting PnL 0
 	for _, party := range sParties {
-		rewards := 0.0
-		dataFormatted := "0.0"
+		rewards := decimal.Zero
+		dataFormatted := formatAmount(decimal.Zero, asset)
 		if len(party.RewardsConnection.Edges) != 0 {
 			for _, w := range party.RewardsConnection.Edges {
-				if err != nil {
-					fmt.Errorf("failed to convert Transfer amount to string", err)
-				}
 				if w.Reward.Asset.Id == s.cfg.VegaAssets[0] &&
 					w.Reward.ReceivedAt.After(s.cfg.StartTime) &&
 					w.Reward.ReceivedAt.Before(s.cfg.EndTime) &&
 					w.Reward.RewardType == "ACCOUNT_TYPE_REWARD_MAKER_RECEIVED_FEES" {
-					rewards1, err := strconv.ParseFloat(w.Reward.Amount, 64)
+					rewards1, err := decimal.NewFromString(w.Reward.Amount)
 					if err != nil {
-						return nil, fmt.Errorf("failed to convert reward amount into float: %w", err)
+						return nil, fmt.Errorf("failed to convert reward amount into decimal: %w", err)
 					}
-					rewards += rewards1
+					rewards = rewards.Add(rewards1.Shift(-int32(asset.Decimals)))
 				}
 			}
 		}
 
-		if rewards != 0.0 {
-			if party.blacklisted {
-				log.Infof("Blacklisted party added: %d, %s, %s", party.twitterID, party.social, party.ID)
-			}
+		if !rewards.IsZero() {
 
 			t := time.Now().UTC()
-			if rewards != 0 {
-				dpMultiplier := math.Pow(10, decimalPlaces)
-				total := rewards / dpMultiplier
-				dataFormatted = strconv.FormatFloat(total, 'f', 10, 32)
+			if !rewards.IsZero() {
+				dataFormatted = formatAmount(rewards, asset)
 			}
 
+			breakdown := s.rewardsBreakdown(&party, asset, "ACCOUNT_TYPE_REWARD_MAKER_RECEIVED_FEES", rewards)
+
 			participants = append(participants, Participant{
-				PublicKey:     party.ID,
-				Data:          []string{dataFormatted},
-				sortNum:       rewards,
-				CreatedAt:     t,
-				UpdatedAt:     t,
-				isBlacklisted: party.blacklisted,
+				PublicKey: party.ID,
+				Data:      []string{dataFormatted},
+				Metrics:   Metrics{amountMetric("rewards", rewards, asset)},
+				breakdown: breakdown,
+				sortNum:   rewards,
+				CreatedAt: t,
+				UpdatedAt: t,
 			})
 		}
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum > participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortDescending(participants)
 
 	return participants, nil
 }
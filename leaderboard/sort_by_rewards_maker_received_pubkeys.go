@@ -1,165 +1,76 @@
 package leaderboard
 
 import (
-	"context"
 	"fmt"
-	"math"
-	"sort"
-	"strconv"
 	"time"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/shopspring/decimal"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
-var gqlQueryPartiesAccountsMakerReceivedPubkeys string = `query ($pagination: Pagination!) {
-	partiesConnection(pagination: $pagination) {
-	  edges {
-		node {
-		  id
-		  rewardsConnection {
-			edges {
-			  node {
-				amount
-				asset {
-				  id
-				}
-				marketId
-				rewardType
-				receivedAt
-			  }
-			}
-		  }
-		}
-	  }
-	  pageInfo {
-		hasNextPage
-		hasPreviousPage
-		startCursor
-		endCursor
-	  }
-	}
-  }`
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"ByPartyRewardsMakerReceivedPubkeys",
+		nil,
+		[]string{"rewards"},
+		(*Service).sortByPartyRewardsMakerReceivedPubkeys,
+	))
+}
 
 func (s *Service) sortByPartyRewardsMakerReceivedPubkeys(socials map[string]verifier.Social) ([]Participant, error) {
 
-	// Grab the DP we're targeting (for the asset we're interested in for the market specified
-	decimalPlacesStr, err := s.getAlgorithmConfig("decimalPlaces")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
-	}
-	decimalPlaces, err := strconv.ParseFloat(decimalPlacesStr, 64)
+	asset, err := s.settlementAsset()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get algorithm config: %s", err)
+		return nil, err
 	}
 
-	pagination := Pagination{First: 50}
-
-	ctx := context.Background()
-	partyEdges := []PartiesEdge{}
-	for {
-		connection, err := getPartiesConnection(
-			ctx,
-			s.cfg.VegaGraphQLURL.String(),
-			gqlQueryPartiesAccountsMakerReceived,
-			map[string]interface{}{"pagination": pagination},
-			nil,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get list of parties in loop: %w", err)
-		}
-
-		partyEdges = append(partyEdges, connection.Edges...)
-
-		// fmt.Println("got ", len(partyEdges), "end?", connection.PageInfo.EndCursor)
-
-		if !connection.PageInfo.NextPage {
-			// fmt.Println("done")
-			break
-		} else {
-			pagination.After = connection.PageInfo.EndCursor
-		}
-
+	ctx := s.ctx
+	partyEdges, err := s.fetchAllParties(ctx, "rewards")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of parties: %w", err)
 	}
 	participants := []Participant{}
 	// if participant in JSON, PNL = json data, otherwise starting PnL 0
 	for _, party := range partyEdges {
-		rewards := 0.0
-		dataFormatted := "0.0"
+		rewards := decimal.Zero
+		dataFormatted := formatAmount(decimal.Zero, asset)
 		if len(party.Party.RewardsConnection.Edges) != 0 {
 			for _, w := range party.Party.RewardsConnection.Edges {
-				if err != nil {
-					fmt.Errorf("failed to convert Transfer amount to string", err)
-				}
 				if w.Reward.Asset.Id == s.cfg.VegaAssets[0] &&
 					w.Reward.ReceivedAt.After(s.cfg.StartTime) &&
 					w.Reward.ReceivedAt.Before(s.cfg.EndTime) &&
 					w.Reward.RewardType == "ACCOUNT_TYPE_REWARD_MAKER_RECEIVED_FEES" {
-					rewards1, err := strconv.ParseFloat(w.Reward.Amount, 64)
+					rewards1, err := decimal.NewFromString(w.Reward.Amount)
 					if err != nil {
-						return nil, fmt.Errorf("failed to convert reward amount into float: %w", err)
+						return nil, fmt.Errorf("failed to convert reward amount into decimal: %w", err)
 					}
-					rewards += rewards1
+					rewards = rewards.Add(rewards1.Shift(-int32(asset.Decimals)))
 				}
 			}
 		}
 
-		if rewards != 0.0 {
-			if party.Party.blacklisted {
-				log.Infof("Blacklisted party added: %d, %s, %s", party.Party.ID)
-			}
-
-			pubkeyBlacklist := []string{"93e8077e3c0a942bd5469b3b142ffe643f4d9c5d9962a862de419bfc5f8bfeb9",
-				"4af3e8fe168095cc20c9232f7b4723645a59c6717eb4e9d2121378c6002fb4bb",
-				"ad0549439a1c15ebffc2ff406451eec560facfabe762b3a5401e3d20d384d5b3",
-				"19e675aa6a7747ee504e31adc660aa9df4213a8ca9d1243e4a30d0974dac3705",
-				"45c231260e56ba839f8cc0a4ccec16a209965f6534887ff19c3bfc9242bfc844",
-				"fdab1c1c9db496f651d922e3b056a4736e3a3b0ee301cb20afa491f3656939d8",
-				"1d2247dff85b9396d46545cb959e3b5c5925dd77e193ba873f01f4079481f67b",
-				"937802822f779d3d14b280ffdabcd2935c8b7c708a6ca53b8c05230827c8a960",
-				"86ff2c3b45be7c43202d1dc370779d070faaba1029094c46174857f1b445673f",
-				"51351917f4400efe8ecdd1ecc726d174bbd87c991dc0795863cadd586b4e3865",
-				"2aaeeeec54b72fcf69d89b2f5960dea1b9bca2cc71a61f421502a80fff32c139",
-				"022a129df7f8360c9de598e8d0eeb06c62c9f63393b25024af89cd5bfb1c6207",
-				"09a576a282cbafe3b37673949f7d563118222d4fbfa3df7879727667d4970577",
-				"0b8519bb08e11dac3ab1073f4fc8cd0ff02e5b751d14c5e624c26bf65c7aaf92",
-				"feae764c6615a4e9c0170a500e1d51d312b4b8e50bd59fa825843025bff4fe02",
-				"4a9b97bd45af1a9d744462edcb67a249165065984667914db2d2167a0d45221d",
-				"82507ccb7b6380dc36eae68bdbc5495d2b9126ce00f49bce911f6ad6a0c1359d",
-				"c810aa6c86b3c367248cc41277970cf97ef95568451338d72972a41fddd079a2",
-				"edae7973f562cd232ff15094509655b4d31c6d7fd5f8a45db5965572e65c4d54"}
-
-			for _, pubkey := range pubkeyBlacklist {
-				if pubkey == party.Party.ID {
-					party.Party.blacklisted = true
-				}
-			}
-
+		if !rewards.IsZero() {
 			t := time.Now().UTC()
-			if rewards != 0 {
-				dpMultiplier := math.Pow(10, decimalPlaces)
-				total := rewards / dpMultiplier
-				dataFormatted = strconv.FormatFloat(total, 'f', 10, 32)
+			if !rewards.IsZero() {
+				dataFormatted = formatAmount(rewards, asset)
 			}
 
-			if party.Party.blacklisted == false {
-				participants = append(participants, Participant{
-					PublicKey:     party.Party.ID,
-					Data:          []string{dataFormatted},
-					sortNum:       rewards,
-					CreatedAt:     t,
-					UpdatedAt:     t,
-					isBlacklisted: party.Party.blacklisted,
-				})
-			}
+			breakdown := s.rewardsBreakdown(&party.Party, asset, "ACCOUNT_TYPE_REWARD_MAKER_RECEIVED_FEES", rewards)
+
+			participants = append(participants, Participant{
+				PublicKey: party.Party.ID,
+				Data:      []string{dataFormatted},
+				Metrics:   Metrics{amountMetric("rewards", rewards, asset)},
+				breakdown: breakdown,
+				sortNum:   rewards,
+				CreatedAt: t,
+				UpdatedAt: t,
+			})
 
 		}
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum > participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortDescending(participants)
 
 	return participants, nil
 }
@@ -1,13 +1,24 @@
 package leaderboard
 
 import (
-	"sort"
 	"strings"
 
+	"github.com/shopspring/decimal"
 	"github.com/vegaprotocol/topgun-service/util"
 	"github.com/vegaprotocol/topgun-service/verifier"
 )
 
+func init() {
+	RegisterAlgorithm(NewAlgorithm(
+		"BySocialRegistration",
+		nil,
+		[]string{"registered"},
+		func(s *Service, _ map[string]verifier.Social) ([]Participant, error) {
+			return s.sortBySocialRegistration(s.verifier.List())
+		},
+	))
+}
+
 func (s *Service) sortBySocialRegistration(socials []verifier.Social) ([]Participant, error) {
 
 	// A leaderboard to show only registered social accounts, used to verify that they have
@@ -18,26 +29,35 @@ func (s *Service) sortBySocialRegistration(socials []verifier.Social) ([]Partici
 	existing := make(map[string]byte, 0)
 
 	for _, s := range socials {
-		handle := strings.ToLower(s.TwitterHandle)
+		handle := s.Provider + ":" + strings.ToLower(s.Handle)
+		if s.Handle == "" {
+			// Wallet-only identities have no handle to share
+			handle = s.Provider + ":" + s.PartyID
+		}
 		if _, found := existing[handle]; !found {
 			// Keep a map of found social handles
 			// Note: dupes appear in the list returned from the SMV-API
 			existing[handle] = 0xF
 			count++
+			// Nothing is fetched from Vega, the order of registration is the score
+			breakdown := &ScoreBreakdown{Terms: []TermBreakdown{}, Assets: []string{}}
+			breakdown.addTerm("registration", decimal.NewFromInt(int64(count)), false)
+			breakdown.Notes = append(breakdown.Notes, "registered as "+handle)
+			breakdown.Score = decimal.NewFromInt(int64(count)).String()
+
 			participants = append(participants, Participant{
 				PublicKey: s.PartyID,
 				CreatedAt: util.TimeFromUnixTimeStamp(s.CreatedAt),
 				UpdatedAt: util.TimeFromUnixTimeStamp(s.UpdatedAt),
 				Data:      []string{"Registered"},
-				sortNum:   float64(count),
+				Metrics:   Metrics{flagMetric("registered")},
+				breakdown: breakdown,
+				sortNum:   decimal.NewFromInt(int64(count)),
 			})
 		}
 	}
 
-	sortFunc := func(i, j int) bool {
-		return participants[i].sortNum > participants[j].sortNum
-	}
-	sort.Slice(participants, sortFunc)
+	sortDescending(participants)
 
 	return participants, nil
 }
@@ -0,0 +1,72 @@
+package leaderboard_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/vegaprotocol/topgun-service/config"
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sortedBoard(t *testing.T, svc *leaderboard.Service, metric string, order string) leaderboard.Leaderboard {
+	by, err := leaderboard.NewSort(metric, order)
+	require.NoError(t, err)
+	payload, err := svc.JsonLeaderboard("", 0, 0, false, by)
+	require.NoError(t, err)
+	var board leaderboard.Leaderboard
+	require.NoError(t, json.Unmarshal(payload, &board))
+	return board
+}
+
+func TestSortReranksTheView(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL - transfers"})
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	// The algorithm ranks p2 (9000) above p1 (7000)
+	board := sortedBoard(t, svc, "Score", "asc")
+	require.Equal(t, []string{"p1", "p2"}, publicKeys(board))
+	require.Equal(t, []int{1, 2}, positions(board))
+
+	board = sortedBoard(t, svc, "score", "")
+	require.Equal(t, []string{"p2", "p1"}, publicKeys(board))
+	require.Equal(t, []int{1, 2}, positions(board))
+
+	// The board itself keeps the algorithm's ranking
+	require.Equal(t, []string{"p2", "p1"}, publicKeys(currentBoard(t, svc)))
+
+	_, err := svc.JsonLeaderboard("", 0, 0, false, leaderboard.Sort{Metric: "luck", Order: leaderboard.SortDescending})
+	require.True(t, errors.Is(err, leaderboard.ErrUnknownMetric), err)
+}
+
+func TestSortSharesPositionsOfTies(t *testing.T) {
+	cfg := newPipelineTestConfig(t, map[string]string{"metric": "unrealisedPnL", "include": "all"})
+	cfg.Ranking = config.RankingShared
+	svc := leaderboard.NewLeaderboardService(cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	// Every party has an unrealised PnL of 0
+	board := sortedBoard(t, svc, "score", "asc")
+	require.NotEmpty(t, board.Participants)
+	for _, position := range positions(board) {
+		require.Equal(t, 1, position)
+	}
+}
+
+func TestNewSort(t *testing.T) {
+	by, err := leaderboard.NewSort("pnl", "ASC")
+	require.NoError(t, err)
+	require.Equal(t, leaderboard.Sort{Metric: "pnl", Order: leaderboard.SortAscending}, by)
+
+	by, err = leaderboard.NewSort("", "asc")
+	require.NoError(t, err)
+	require.Equal(t, leaderboard.Sort{}, by)
+
+	_, err = leaderboard.NewSort("pnl", "sideways")
+	require.Equal(t, leaderboard.ErrInvalidOrder, err)
+}
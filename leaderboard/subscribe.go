@@ -0,0 +1,47 @@
+package leaderboard
+
+// Subscribe returns a channel that receives a value whenever an update publishes a new
+// board, and a function to unsubscribe. Subscribers read the board themselves, e.g. with
+// JsonLeaderboard, and notifications are coalesced, so a slow subscriber only misses
+// intermediate boards. The channel is closed when the service stops.
+func (s *Service) Subscribe() (<-chan struct{}, func()) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	ch := make(chan struct{}, 1)
+	if s.subscribersClosed {
+		close(ch)
+		return ch, func() {}
+	}
+	if s.subscribers == nil {
+		s.subscribers = map[chan struct{}]struct{}{}
+	}
+	s.subscribers[ch] = struct{}{}
+	return ch, func() {
+		s.subscribersMu.Lock()
+		defer s.subscribersMu.Unlock()
+		delete(s.subscribers, ch)
+	}
+}
+
+// notifySubscribers notifies every subscriber of a new board, without blocking.
+func (s *Service) notifySubscribers() {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// closeSubscribers closes every subscription, so streams end when the service stops.
+func (s *Service) closeSubscribers() {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	for ch := range s.subscribers {
+		close(ch)
+	}
+	s.subscribers = nil
+	s.subscribersClosed = true
+}
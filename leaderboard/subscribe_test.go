@@ -0,0 +1,48 @@
+package leaderboard_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/leaderboard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribersAreNotifiedOfNewBoards(t *testing.T) {
+	svc := leaderboard.NewLeaderboardService(newPipelineTestConfig(t, map[string]string{"metric": "realisedPnL"}))
+	updates, unsubscribe := svc.Subscribe()
+	defer unsubscribe()
+
+	svc.Start()
+	requireNotified(t, updates)
+
+	// Notifications are coalesced while the subscriber is busy
+	svc.Recompute()
+	svc.Recompute()
+	requireNotified(t, updates)
+
+	svc.Stop()
+	require.Eventually(t, func() bool {
+		select {
+		case _, open := <-updates:
+			return !open
+		default:
+			return false
+		}
+	}, time.Second, 5*time.Millisecond)
+
+	late, _ := svc.Subscribe()
+	_, open := <-late
+	require.False(t, open)
+}
+
+func requireNotified(t *testing.T, updates <-chan struct{}) {
+	t.Helper()
+	select {
+	case _, open := <-updates:
+		require.True(t, open)
+	case <-time.After(time.Second):
+		t.Fatal("no notification")
+	}
+}
@@ -0,0 +1,153 @@
+// Package metrics holds the Prometheus metrics of the service, served on /metrics. They are
+// registered with the default registry, which also collects the Go runtime and process metrics.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "topgun"
+
+// Results of an update, request or sync.
+const (
+	ResultSuccess    = "success"
+	ResultError      = "error"
+	ResultQueryError = "query_error"
+)
+
+var (
+	updateDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "update_duration_seconds",
+		Help:      "Duration of leaderboard updates, from the verifier sync to the scored participants.",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"competition", "algorithm", "result"})
+
+	graphQLRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "graphql_requests_total",
+		Help:      "Data node GraphQL requests by query and result, counting every attempt.",
+	}, []string{"query", "result"})
+
+	graphQLDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "graphql_request_duration_seconds",
+		Help:      "Latency of data node GraphQL requests by query.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"query"})
+
+	verifierSyncs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "verifier_syncs_total",
+		Help:      "Syncs of the verified socials by result.",
+	}, []string{"result"})
+
+	participants = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "participants",
+		Help:      "Participants of the last successful update: registered socials, ranked and blacklisted participants.",
+	}, []string{"competition", "state"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP API requests by route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "code"})
+)
+
+// ObserveUpdate records the duration and result of a leaderboard update.
+func ObserveUpdate(competition string, algorithm string, duration time.Duration, err error) {
+	updateDuration.WithLabelValues(competition, algorithm, result(err)).Observe(duration.Seconds())
+}
+
+// SetParticipants records the participant counts of a successful leaderboard update.
+func SetParticipants(competition string, registered int, ranked int, blacklisted int) {
+	participants.WithLabelValues(competition, "registered").Set(float64(registered))
+	participants.WithLabelValues(competition, "ranked").Set(float64(ranked))
+	participants.WithLabelValues(competition, "blacklisted").Set(float64(blacklisted))
+}
+
+// ObserveGraphQLRequest records a single attempt of a data node request, with one of the
+// Result constants.
+func ObserveGraphQLRequest(query string, duration time.Duration, result string) {
+	name := QueryName(query)
+	graphQLRequests.WithLabelValues(name, result).Inc()
+	graphQLDuration.WithLabelValues(name).Observe(duration.Seconds())
+}
+
+// ObserveVerifierSync records the result of a sync of the verified socials.
+func ObserveVerifierSync(err error) {
+	verifierSyncs.WithLabelValues(result(err)).Inc()
+}
+
+func result(err error) string {
+	if err != nil {
+		return ResultError
+	}
+	return ResultSuccess
+}
+
+// QueryName names a GraphQL query by its first top-level field, e.g. "positions". The
+// service sends anonymous queries, so the operation name cannot be used.
+func QueryName(query string) string {
+	start := strings.Index(query, "{")
+	if start < 0 {
+		return "unknown"
+	}
+	field := strings.TrimLeftFunc(query[start+1:], unicode.IsSpace)
+	end := strings.IndexFunc(field, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if end >= 0 {
+		field = field[:end]
+	}
+	if len(field) == 0 {
+		return "unknown"
+	}
+	return field
+}
+
+// Middleware records the latency of HTTP requests by route template, e.g.
+// /competitions/{id}/leaderboard, so that path parameters do not create new series.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		httpDuration.WithLabelValues(route, strconv.Itoa(recorder.status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusRecorder keeps the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush passes flushes through for streamed responses.
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,38 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vegaprotocol/topgun-service/metrics"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryName(t *testing.T) {
+	require.Equal(t, "assetsConnection", metrics.QueryName("{\n\tassetsConnection {\n\t  edges {"))
+	require.Equal(t, "positions", metrics.QueryName("query ($marketId: [ID!]){\n\tpositions (filter: {marketIds: $marketId}) {"))
+	require.Equal(t, "unknown", metrics.QueryName("query"))
+}
+
+func TestMiddlewareRecordsRouteTemplates(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(metrics.Middleware)
+	router.Handle("/metrics", promhttp.Handler())
+	router.HandleFunc("/competitions/{id}/leaderboard", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/competitions/pnl/leaderboard", nil))
+	metrics.ObserveGraphQLRequest("{ assetsConnection { edges } }", 0, metrics.ResultSuccess)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := recorder.Body.String()
+	require.True(t, strings.Contains(body, `topgun_http_request_duration_seconds_count{code="404",route="/competitions/{id}/leaderboard"} 1`), body)
+	require.True(t, strings.Contains(body, `topgun_graphql_requests_total{query="assetsConnection",result="success"} 1`), body)
+}
@@ -5,11 +5,18 @@ import (
 )
 
 type ParticipantCsvEntry struct { // Note: use "-" to ignore a field
-	Position      int       `csv:"position"`
-	TwitterHandle string    `csv:"twitter_handle"`
-	TwitterID     int64     `csv:"twitter_user_id"`
-	CreatedAt     time.Time `csv:"created_at"`
-	UpdatedAt     time.Time `csv:"updated_at"`
-	VegaPubKey    string    `csv:"vega_pubkey"`
-	VegaData      string    `csv:"vega_data"`
+	Position       int       `csv:"position"`
+	Provider       string    `csv:"provider"`
+	Handle         string    `csv:"handle"`
+	ProviderUserID string    `csv:"provider_user_id"`
+	CreatedAt      time.Time `csv:"created_at"`
+	UpdatedAt      time.Time `csv:"updated_at"`
+	VegaPubKey     string    `csv:"vega_pubkey"`
+	VegaData       string    `csv:"vega_data"`
+
+	PreviousPosition  int    `csv:"previous_position"`
+	PositionChange    int    `csv:"position_change"`
+	ReferencePosition int    `csv:"reference_position"`
+	ReferenceChange   int    `csv:"reference_change"`
+	ScoreChange       string `csv:"score_change"`
 }
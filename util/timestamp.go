@@ -2,6 +2,7 @@ package util
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -12,3 +13,15 @@ func UnixTimestampUtcNowFormatted() string {
 func TimeFromUnixTimeStamp(unixTimestamp int64) time.Time {
 	return time.Unix(unixTimestamp, 0)
 }
+
+// ParseTimestamp accepts either an RFC3339 time or a unix timestamp in seconds.
+func ParseTimestamp(value string) (time.Time, error) {
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return TimeFromUnixTimeStamp(unix).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q, expected RFC3339 or unix seconds", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,45 @@
+package verifier
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+	"github.com/pkg/errors"
+)
+
+// FileVerifier loads socials from a local file. A file ending in .csv has a header row
+// naming the fields, e.g. party_id,provider,handle,provider_user_id,created. Any other
+// file holds a JSON array of socials, as returned by the verifier service.
+type FileVerifier struct {
+	path string
+}
+
+// NewFileVerifier creates a Verifier reading the file at path on every load, so the file
+// can be edited while the service runs.
+func NewFileVerifier(path string) *FileVerifier {
+	return &FileVerifier{path: path}
+}
+
+func (v *FileVerifier) Load() ([]Social, error) {
+	content, err := ioutil.ReadFile(v.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read socials file")
+	}
+	records := []record{}
+	if strings.EqualFold(filepath.Ext(v.path), ".csv") {
+		err = gocsv.UnmarshalBytes(content, &records)
+	} else {
+		err = json.Unmarshal(content, &records)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse socials file %s", v.path)
+	}
+	return socialsFromRecords(records), nil
+}
+
+func (v *FileVerifier) String() string {
+	return "file " + v.path
+}
@@ -0,0 +1,49 @@
+package verifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// HTTPVerifier loads socials from the social media verification API, which returns a
+// JSON array of socials with Twitter or generic identity fields.
+type HTTPVerifier struct {
+	verifyURL url.URL
+}
+
+// NewHTTPVerifier creates a Verifier for the social media verification API at verifyURL.
+func NewHTTPVerifier(verifyURL url.URL) *HTTPVerifier {
+	return &HTTPVerifier{verifyURL: verifyURL}
+}
+
+func (v *HTTPVerifier) Load() ([]Social, error) {
+	resp, err := http.Get(v.verifyURL.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("wrong status code returned from verifier service: %d", resp.StatusCode))
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	// Decode the result
+	var res []record
+	err = json.Unmarshal(body, &res)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal the mapping returned from verifier service")
+	}
+	return socialsFromRecords(res), nil
+}
+
+func (v *HTTPVerifier) String() string {
+	return "verifier service " + v.verifyURL.String()
+}
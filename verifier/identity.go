@@ -0,0 +1,88 @@
+package verifier
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Identity providers a public key can be verified with. A wallet-only identity has no
+// social account, the public key itself is the identity.
+const (
+	ProviderTwitter = "twitter"
+	ProviderDiscord = "discord"
+	ProviderGitHub  = "github"
+	ProviderWallet  = "wallet"
+)
+
+// Identity is the account a public key was verified with.
+type Identity struct {
+	Provider string `json:"provider" bson:"provider,omitempty"`
+	Handle   string `json:"handle" bson:"handle,omitempty"`
+	// UserID is the provider's ID of the account, which unlike the handle does not change
+	UserID string `json:"userId,omitempty" bson:"user_id,omitempty"`
+}
+
+// normalize lowercases the provider. Identities without one are Twitter if they have a
+// handle, as registered through the social media verification API, and wallet-only otherwise.
+func (i Identity) normalize() Identity {
+	i.Provider = strings.ToLower(strings.TrimSpace(i.Provider))
+	if i.Provider == "" {
+		if i.Handle == "" {
+			i.Provider = ProviderWallet
+		} else {
+			i.Provider = ProviderTwitter
+		}
+	}
+	return i
+}
+
+// Key identifies the account across handle changes, e.g. twitter:1355884110619828111. It is
+// the form used in the blacklist.
+func (i Identity) Key() string {
+	return i.Provider + ":" + i.UserID
+}
+
+func (i Identity) String() string {
+	if i.UserID == "" {
+		return i.Provider + ":" + i.Handle
+	}
+	return i.Provider + ":" + i.Handle + " (" + i.UserID + ")"
+}
+
+// record is a social as stored by a Verifier backend, in JSON, CSV or MongoDB documents. It
+// reads both the generic identity fields and the Twitter fields of the social media
+// verification API.
+type record struct {
+	PartyID        string `json:"party_id" csv:"party_id" bson:"party_id"`
+	Provider       string `json:"provider" csv:"provider" bson:"provider"`
+	Handle         string `json:"handle" csv:"handle" bson:"handle"`
+	ProviderUserID string `json:"provider_user_id" csv:"provider_user_id" bson:"provider_user_id"`
+	TwitterHandle  string `json:"twitter_handle" csv:"twitter_handle" bson:"twitter_handle"`
+	TwitterUserID  int64  `json:"twitter_user_id" csv:"twitter_user_id" bson:"twitter_user_id"`
+	CreatedAt      int64  `json:"created" csv:"created" bson:"created"`
+	UpdatedAt      int64  `json:"last_modified" csv:"last_modified" bson:"last_modified"`
+}
+
+func (r record) social() Social {
+	identity := Identity{Provider: r.Provider, Handle: r.Handle, UserID: r.ProviderUserID}
+	if identity.Provider == "" && identity.Handle == "" && r.TwitterHandle != "" {
+		identity = Identity{Provider: ProviderTwitter, Handle: r.TwitterHandle}
+		if r.TwitterUserID != 0 {
+			identity.UserID = strconv.FormatInt(r.TwitterUserID, 10)
+		}
+	}
+	return Social{
+		PartyID:   r.PartyID,
+		Identity:  identity.normalize(),
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+func socialsFromRecords(records []record) []Social {
+	socials := make([]Social, 0, len(records))
+	for _, r := range records {
+		socials = append(socials, r.social())
+	}
+	return socials
+}
@@ -0,0 +1,37 @@
+package verifier
+
+import (
+	"github.com/vegaprotocol/topgun-service/datastore"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// MongoVerifier loads socials from a MongoDB collection, one document per public key with
+// the same fields as a socials file.
+type MongoVerifier struct {
+	ds             *datastore.Service
+	databaseName   string
+	collectionName string
+}
+
+// NewMongoVerifier creates a Verifier reading the given collection through a connected datastore.
+func NewMongoVerifier(ds *datastore.Service, databaseName string, collectionName string) *MongoVerifier {
+	return &MongoVerifier{
+		ds:             ds,
+		databaseName:   databaseName,
+		collectionName: collectionName,
+	}
+}
+
+func (v *MongoVerifier) Load() ([]Social, error) {
+	records := []record{}
+	err := v.ds.FindDocuments(v.databaseName, v.collectionName, bson.D{}, bson.D{{Key: "created", Value: 1}}, nil, &records)
+	if err != nil {
+		return nil, err
+	}
+	return socialsFromRecords(records), nil
+}
+
+func (v *MongoVerifier) String() string {
+	return "MongoDB collection " + v.databaseName + "." + v.collectionName
+}
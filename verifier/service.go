@@ -1,13 +1,10 @@
 package verifier
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
-	"net/url"
-	"strconv"
 	"sync"
+	"time"
+
+	"github.com/vegaprotocol/topgun-service/metrics"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -17,29 +14,32 @@ type Socials struct {
 	Socials []Social
 }
 
+// Social is a Vega public key verified as belonging to an Identity.
 type Social struct {
-	PartyID       string `json:"party_id"`
-	TwitterHandle string `json:"twitter_handle"`
-	TwitterUserID int64  `json:"twitter_user_id"`
-	CreatedAt     int64  `json:"created"`
-	UpdatedAt     int64  `json:"last_modified"`
-	IsBlacklisted bool   `json:"is_blacklisted"`
+	PartyID string
+	Identity
+	CreatedAt int64
+	UpdatedAt int64
 }
 
 type Service struct {
 	mu         sync.RWMutex
-	blacklist  map[string]string
 	socialList *Socials
-	verifyURL  url.URL
+	verifier   Verifier
+
+	// syncMu guards the sync status, which is read while a sync holds mu
+	syncMu   sync.Mutex
+	lastSync time.Time
+	syncErr  error
 }
 
-func NewVerifierService(verifyURL url.URL, blacklist map[string]string) *Service {
+// NewVerifierService creates a service that keeps the socials loaded from a Verifier.
+func NewVerifierService(verifier Verifier) *Service {
 	socialList := make([]Social, 0)
 	socialHolder := Socials{Socials: socialList}
 	s := Service{
-		verifyURL:  verifyURL,
+		verifier:   verifier,
 		socialList: &socialHolder,
-		blacklist: blacklist,
 	}
 	return &s
 }
@@ -48,15 +48,22 @@ func (s *Service) UpdateVerifiedParties() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	log.Info("Syncing verified parties from external social verifier API service")
+	log.Infof("Syncing verified parties from %s", s.verifier)
 	socials, err := s.loadVerifiedParties()
+	metrics.ObserveVerifierSync(err)
+	s.syncMu.Lock()
+	if err == nil {
+		s.lastSync = time.Now()
+	}
+	s.syncErr = err
+	s.syncMu.Unlock()
 	previousSocialList := s.getSocialList()
 
 	foundTotal := 0
 	if err != nil {
 		log.Error(errors.Wrap(err, "failed to update/sync verified parties"))
 	} else {
-		log.Info("Verified parties loaded from external API service")
+		log.Infof("Verified parties loaded from %s", s.verifier)
 		foundTotal = len(socials.Socials)
 		s.socialList = socials
 	}
@@ -64,27 +71,19 @@ func (s *Service) UpdateVerifiedParties() {
 	log.Infof("Parties found: %d, last total: %d", foundTotal, len(previousSocialList))
 }
 
+// LastSync returns the time of the last successful sync, zero if there was none, and the
+// error of the last sync, nil if it succeeded.
+func (s *Service) LastSync() (time.Time, error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	return s.lastSync, s.syncErr
+}
+
 func (s *Service) List() []Social {
 	soc := *s.socialList
 	return soc.Socials
 }
 
-func (s *Service) processBlacklisted(socials []Social) []Social {
-	if socials == nil {
-		return nil
-	}
-	socialList := make([]Social, 0)
-	for _, soc := range socials {
-		sUID:= strconv.FormatInt(soc.TwitterUserID, 10)
-		if _, found := s.blacklist[sUID]; found {
-			log.Infof("Found blacklisted user: %s - %d", soc.TwitterHandle, soc.TwitterUserID)
-			soc.IsBlacklisted = true
-		}
-		socialList = append(socialList, soc)
-	}
-	return socialList
-}
-
 func (s *Service) getSocialList() []Social {
 	socialList := Socials{}
 	if s.socialList != nil {
@@ -103,26 +102,9 @@ func (s *Service) PubKeysToSocials() map[string]Social {
 }
 
 func (s *Service) loadVerifiedParties() (*Socials, error) {
-	resp, err := http.Get(s.verifyURL.String())
+	found, err := s.verifier.Load()
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-		// Decode the result
-		var res []Social
-		err = json.Unmarshal(body, &res)
-		if err != nil {
-			return nil, errors.Wrap(err, "unable to unmarshal the mapping returned from verifier service")
-		}
-		found := res
-		return &Socials{Socials: s.processBlacklisted(found)}, nil
-	} else {
-		return nil, errors.New(fmt.Sprintf("wrong status code returned from verifier service: %d", resp.StatusCode))
-	}
+	return &Socials{Socials: found}, nil
 }
@@ -0,0 +1,85 @@
+package verifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// Verifier loads the verified socials from one backend, e.g. the social media verification
+// API, a file or a database. Implementations describe their backend in String, for logs.
+type Verifier interface {
+	fmt.Stringer
+	Load() ([]Social, error)
+}
+
+// StaticVerifier is a fixed list of socials, e.g. from the config of a test competition.
+type StaticVerifier struct {
+	socials []Social
+}
+
+// NewStaticVerifier creates a Verifier that always returns the given socials.
+func NewStaticVerifier(socials []Social) *StaticVerifier {
+	normalized := make([]Social, 0, len(socials))
+	for _, social := range socials {
+		social.Identity = social.Identity.normalize()
+		normalized = append(normalized, social)
+	}
+	return &StaticVerifier{socials: normalized}
+}
+
+func (v *StaticVerifier) Load() ([]Social, error) {
+	socials := make([]Social, len(v.socials))
+	copy(socials, v.socials)
+	return socials, nil
+}
+
+func (v *StaticVerifier) String() string {
+	return fmt.Sprintf("static list (%d socials)", len(v.socials))
+}
+
+// CompositeVerifier merges the socials of several verifiers. A public key found by more
+// than one verifier keeps the social of the first, in the order given.
+type CompositeVerifier struct {
+	verifiers []Verifier
+}
+
+// NewCompositeVerifier creates a Verifier merging the socials of the given verifiers.
+func NewCompositeVerifier(verifiers ...Verifier) *CompositeVerifier {
+	return &CompositeVerifier{verifiers: verifiers}
+}
+
+// Load fails if any of the verifiers fails, so a partial list never replaces a full one.
+func (v *CompositeVerifier) Load() ([]Social, error) {
+	var e *multierror.Error
+	seen := map[string]bool{}
+	merged := []Social{}
+	for _, verifier := range v.verifiers {
+		socials, err := verifier.Load()
+		if err != nil {
+			e = multierror.Append(e, errors.Wrapf(err, "failed to load socials from %s", verifier))
+			continue
+		}
+		for _, social := range socials {
+			if social.PartyID == "" || seen[social.PartyID] {
+				continue
+			}
+			seen[social.PartyID] = true
+			merged = append(merged, social)
+		}
+	}
+	if err := e.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+func (v *CompositeVerifier) String() string {
+	names := make([]string, 0, len(v.verifiers))
+	for _, verifier := range v.verifiers {
+		names = append(names, verifier.String())
+	}
+	return strings.Join(names, ", ")
+}
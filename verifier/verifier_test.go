@@ -0,0 +1,82 @@
+package verifier_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/vegaprotocol/topgun-service/verifier"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeSocialsFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func partyIDs(socials []verifier.Social) []string {
+	ids := []string{}
+	for _, social := range socials {
+		ids = append(ids, social.PartyID)
+	}
+	return ids
+}
+
+func TestFileVerifierReadsJSONAndCSV(t *testing.T) {
+	jsonFile := writeSocialsFile(t, "socials.json", `[
+		{"party_id": "p1", "twitter_handle": "one", "twitter_user_id": 1, "created": 1679040000}
+	]`)
+	socials, err := verifier.NewFileVerifier(jsonFile).Load()
+	require.NoError(t, err)
+	require.Equal(t, []verifier.Social{{
+		PartyID:   "p1",
+		Identity:  verifier.Identity{Provider: "twitter", Handle: "one", UserID: "1"},
+		CreatedAt: 1679040000,
+	}}, socials)
+
+	csvFile := writeSocialsFile(t, "socials.csv", "party_id,provider,handle,provider_user_id,created\np2,Discord,two#0002,80351110224678912,1679043600\np3,,,,1679047200\n")
+	socials, err = verifier.NewFileVerifier(csvFile).Load()
+	require.NoError(t, err)
+	require.Equal(t, []verifier.Social{{
+		PartyID:   "p2",
+		Identity:  verifier.Identity{Provider: "discord", Handle: "two#0002", UserID: "80351110224678912"},
+		CreatedAt: 1679043600,
+	}, {
+		PartyID:   "p3",
+		Identity:  verifier.Identity{Provider: "wallet"},
+		CreatedAt: 1679047200,
+	}}, socials)
+
+	_, err = verifier.NewFileVerifier(filepath.Join(t.TempDir(), "missing.json")).Load()
+	require.Error(t, err)
+}
+
+func TestCompositeVerifierMergesInOrder(t *testing.T) {
+	file := writeSocialsFile(t, "socials.json", `[{"party_id": "p1", "provider": "github", "handle": "from-file"}]`)
+	composite := verifier.NewCompositeVerifier(
+		verifier.NewFileVerifier(file),
+		verifier.NewStaticVerifier([]verifier.Social{
+			{PartyID: "p1", Identity: verifier.Identity{Handle: "from-config"}},
+			{PartyID: "p2", Identity: verifier.Identity{Handle: "two"}},
+		}),
+	)
+
+	socials, err := composite.Load()
+	require.NoError(t, err)
+	require.Equal(t, []string{"p1", "p2"}, partyIDs(socials))
+	require.Equal(t, verifier.Identity{Provider: "github", Handle: "from-file"}, socials[0].Identity)
+	require.Equal(t, verifier.Identity{Provider: "twitter", Handle: "two"}, socials[1].Identity)
+}
+
+func TestCompositeVerifierFailsIfAnyVerifierFails(t *testing.T) {
+	composite := verifier.NewCompositeVerifier(
+		verifier.NewStaticVerifier([]verifier.Social{{PartyID: "p1"}}),
+		verifier.NewFileVerifier(filepath.Join(t.TempDir(), "missing.json")),
+	)
+
+	// A partial list would drop registered parties from the leaderboard
+	_, err := composite.Load()
+	require.Error(t, err)
+}